@@ -1,37 +1,260 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
+// LoadViperConfigs is like LoadViperConfigsErr but panics on error.
 func LoadViperConfigs(paths ...string) *viper.Viper {
+	conf, err := LoadViperConfigsErr(paths...)
+	if err != nil {
+		panic(err)
+	}
+
+	return conf
+}
+
+// LoadViperConfigsErr reads the config files in order, later files overriding earlier ones.
+func LoadViperConfigsErr(paths ...string) (*viper.Viper, error) {
 	conf := viper.New()
 
 	for _, path := range paths {
 		pathConf := viper.New()
 		pathConf.SetConfigFile(path)
 		if err := pathConf.ReadInConfig(); err != nil {
-			panic(err)
+			return nil, fmt.Errorf("config: read %s: %w", path, err)
 		}
 		if err := conf.MergeConfigMap(pathConf.AllSettings()); err != nil {
-			panic(err)
+			return nil, fmt.Errorf("config: merge %s: %w", path, err)
 		}
 	}
 
+	return conf, nil
+}
+
+// LoadViperConfigsWithEnv is like LoadViperConfigs but values can be overridden by environment
+// variables named after the key with the prefix, upper-cased and with dots replaced by
+// underscores: with prefix "APP", database.dsn is read from APP_DATABASE_DSN.
+// Env vars take precedence over the config files.
+func LoadViperConfigsWithEnv(prefix string, paths ...string) *viper.Viper {
+	conf := LoadViperConfigs(paths...)
+
+	conf.SetEnvPrefix(prefix)
+	conf.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	conf.AutomaticEnv()
+
 	return conf
 }
 
+// NewViperFromMap is like NewViperFromMapErr but panics on error.
 func NewViperFromMap(ms ...map[string]any) *viper.Viper {
+	conf, err := NewViperFromMapErr(ms...)
+	if err != nil {
+		panic(err)
+	}
+
+	return conf
+}
+
+// NewViperFromMapErr merges the maps in order, later maps overriding earlier ones. Nil maps are skipped.
+// It fails when a nested map has non-string keys, which viper can't address.
+func NewViperFromMapErr(ms ...map[string]any) (*viper.Viper, error) {
 	conf := viper.New()
 
-	for _, m := range ms {
-		if m == nil {
-			continue
+	for i, m := range ms {
+		if err := mergeMap(conf, i, m); err != nil {
+			return nil, err
+		}
+	}
+
+	return conf, nil
+}
+
+// mergeMap merges the i-th map m into conf after checking its nested maps
+func mergeMap(conf *viper.Viper, i int, m map[string]any) error {
+	if m == nil {
+		return nil
+	}
+
+	for key, value := range m {
+		if err := checkKeys(key, value); err != nil {
+			return fmt.Errorf("config: merge map %d: %w", i, err)
 		}
-		if err := conf.MergeConfigMap(m); err != nil {
+	}
+	if err := conf.MergeConfigMap(m); err != nil {
+		return fmt.Errorf("config: merge map %d: %w", i, err)
+	}
+
+	return nil
+}
+
+// checkKeys reports the nested maps of value, found at path, that have non-string keys
+func checkKeys(path string, value any) error {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map {
+		return nil
+	}
+
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, ok := iter.Key().Interface().(string)
+		if !ok {
+			return fmt.Errorf("key %v of %q is a %T, not a string", iter.Key().Interface(), path, iter.Key().Interface())
+		}
+		if err := checkKeys(path+"."+key, iter.Value().Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Precedence decides which value wins when merged configs set the same key.
+type Precedence int
+
+const (
+	// LastWins lets later maps override earlier ones, like NewViperFromMap.
+	LastWins Precedence = iota
+	// FirstWins keeps the values of earlier maps, so later maps only fill in missing keys.
+	FirstWins
+)
+
+// NewViperFromMapOrdered is like NewViperFromMap but precedence decides whether earlier or later
+// maps win on collisions. Nested maps are merged key by key following the same rule.
+// It panics on error.
+func NewViperFromMapOrdered(precedence Precedence, ms ...map[string]any) *viper.Viper {
+	if precedence == LastWins {
+		return NewViperFromMap(ms...)
+	}
+
+	conf := viper.New()
+
+	// Merging in reverse lets viper's deep merge, where the last merged map wins, keep the first values
+	for i := len(ms) - 1; i >= 0; i-- {
+		if err := mergeMap(conf, i, ms[i]); err != nil {
 			panic(err)
 		}
 	}
 
 	return conf
 }
+
+// Unmarshal decodes all settings of conf into a T, honouring `mapstructure` tags and nested structs.
+func Unmarshal[T any](conf *viper.Viper) (T, error) {
+	var v T
+	if err := conf.Unmarshal(&v); err != nil {
+		return v, fmt.Errorf("config: unmarshal: %w", err)
+	}
+
+	return v, nil
+}
+
+// UnmarshalKey is like Unmarshal but only decodes the settings under key.
+func UnmarshalKey[T any](conf *viper.Viper, key string) (T, error) {
+	var v T
+	if err := conf.UnmarshalKey(key, &v); err != nil {
+		return v, fmt.Errorf("config: unmarshal %s: %w", key, err)
+	}
+
+	return v, nil
+}
+
+// WatchedConfig holds the latest snapshot of a config watched by WatchConfig
+type WatchedConfig struct {
+	current atomic.Pointer[viper.Viper]
+}
+
+// Get returns the snapshot of the settings at the last reload. Snapshots are never modified,
+// so they can be read from any goroutine while the file is reloaded.
+func (w *WatchedConfig) Get() *viper.Viper {
+	return w.current.Load()
+}
+
+// WatchConfig watches the file conf was read from and keeps a snapshot of its settings, taken
+// before watching starts and replaced every time the file changes. onChange, if not nil, is then
+// called with the new snapshot. When the changed file can't be read or parsed the previous snapshot
+// is kept and onError, if not nil, is called with the error instead. Only configs read from a single file with SetConfigFile
+// and ReadInConfig can be watched; configs merged by LoadViperConfigs or built from maps can't.
+//
+// The watcher rewrites conf from its own goroutine, so after this call read the settings through
+// WatchedConfig.Get rather than conf. Calls to onChange and onError are serialized.
+func WatchConfig(conf *viper.Viper, onChange func(*viper.Viper), onError func(error)) (*WatchedConfig, error) {
+	if conf.ConfigFileUsed() == "" {
+		return nil, errors.New("config: only configs read from a file can be watched")
+	}
+
+	w := &WatchedConfig{}
+	initial, err := NewViperFromMapErr(conf.AllSettings())
+	if err != nil {
+		return nil, err
+	}
+	w.current.Store(initial)
+
+	var mu sync.Mutex
+	conf.OnConfigChange(func(fsnotify.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// viper only logs a failed reload and keeps the old settings, reading again reports the error
+		if err := conf.ReadInConfig(); err != nil {
+			if onError != nil {
+				onError(fmt.Errorf("config: reload %s: %w", conf.ConfigFileUsed(), err))
+			}
+			return
+		}
+
+		snapshot, err := NewViperFromMapErr(conf.AllSettings())
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+			return
+		}
+		w.current.Store(snapshot)
+		if onChange != nil {
+			onChange(snapshot)
+		}
+	})
+	conf.WatchConfig()
+
+	return w, nil
+}
+
+// MissingKeysError lists the required keys that are missing or empty
+type MissingKeysError struct {
+	Keys []string
+}
+
+func (e *MissingKeysError) Error() string {
+	return "config: missing or empty required keys: " + strings.Join(e.Keys, ", ")
+}
+
+// RequireKeys checks that every key, dotted for nested values (e.g. "database.dsn"), is set
+// to a non-empty value. It returns a *MissingKeysError naming all the offending keys.
+func RequireKeys(conf *viper.Viper, keys ...string) error {
+	var missing []string
+
+	for _, key := range keys {
+		if !conf.IsSet(key) {
+			missing = append(missing, key)
+			continue
+		}
+		if s, ok := conf.Get(key).(string); ok && strings.TrimSpace(s) == "" {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &MissingKeysError{Keys: missing}
+	}
+
+	return nil
+}
@@ -0,0 +1,383 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// writeConfig writes content to a file named name in a temp dir and returns its path
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadViperConfigsWithEnv(t *testing.T) {
+	path := writeConfig(t, "app.yaml", `
+database:
+  dsn: file-dsn
+  driver: mysql
+debug: true
+`)
+	t.Setenv("APP_DATABASE_DSN", "env-dsn")
+	t.Setenv("APP_DEBUG", "false")
+
+	conf := LoadViperConfigsWithEnv("APP", path)
+
+	if got := conf.GetString("database.dsn"); got != "env-dsn" {
+		t.Errorf("database.dsn = %q, want the env override %q", got, "env-dsn")
+	}
+	if got := conf.GetBool("debug"); got {
+		t.Errorf("debug = %v, want the env override false", got)
+	}
+	if got := conf.GetString("database.driver"); got != "mysql" {
+		t.Errorf("database.driver = %q, want the file value %q", got, "mysql")
+	}
+}
+
+func TestLoadViperConfigsErr(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing.yaml")
+
+		if _, err := LoadViperConfigsErr(missing); err == nil || !strings.Contains(err.Error(), missing) {
+			t.Errorf("LoadViperConfigsErr() error = %v, want one naming %s", err, missing)
+		}
+	})
+
+	t.Run("malformed file", func(t *testing.T) {
+		path := writeConfig(t, "bad.yaml", "dsn: [unterminated")
+
+		if _, err := LoadViperConfigsErr(path); err == nil {
+			t.Error("LoadViperConfigsErr() on malformed yaml should fail")
+		}
+	})
+
+	t.Run("later files override", func(t *testing.T) {
+		base := writeConfig(t, "base.yaml", "dsn: base\ndriver: mysql\n")
+		override := writeConfig(t, "override.json", `{"dsn": "override"}`)
+
+		conf, err := LoadViperConfigsErr(base, override)
+		if err != nil {
+			t.Fatalf("LoadViperConfigsErr() error: %v", err)
+		}
+		if conf.GetString("dsn") != "override" || conf.GetString("driver") != "mysql" {
+			t.Errorf("merged config = %v, want dsn override and driver mysql", conf.AllSettings())
+		}
+	})
+
+	t.Run("LoadViperConfigs panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("LoadViperConfigs() with a missing file should panic")
+			}
+		}()
+		LoadViperConfigs(filepath.Join(t.TempDir(), "missing.yaml"))
+	})
+}
+
+func TestNewViperFromMapErr(t *testing.T) {
+	conf, err := NewViperFromMapErr(map[string]any{"dsn": "a", "debug": true}, nil, map[string]any{"dsn": "b"})
+	if err != nil {
+		t.Fatalf("NewViperFromMapErr() error: %v", err)
+	}
+	if conf.GetString("dsn") != "b" || !conf.GetBool("debug") {
+		t.Errorf("merged config = %v, want dsn b and debug true", conf.AllSettings())
+	}
+}
+
+func TestNewViperFromMapErr_nonStringKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		m    map[string]any
+		want string
+	}{
+		{name: "int keys", m: map[string]any{"ports": map[int]string{80: "http"}}, want: `key 80 of "ports"`},
+		{name: "nested any keys", m: map[string]any{"db": map[string]any{"pool": map[any]any{true: 1}}}, want: `"db.pool"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewViperFromMapErr(map[string]any{"ok": 1}, tt.m)
+			if err == nil {
+				t.Fatal("NewViperFromMapErr() succeeded, want an error")
+			}
+			if !strings.Contains(err.Error(), "merge map 1") || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not name map 1 and %s", err, tt.want)
+			}
+		})
+	}
+
+	// Maps with interface keys holding strings, as decoded from YAML, are fine
+	conf, err := NewViperFromMapErr(map[string]any{"db": map[any]any{"dsn": "x"}})
+	if err != nil || conf.GetString("db.dsn") != "x" {
+		t.Errorf("NewViperFromMapErr() = %v, %v; want db.dsn x", conf.AllSettings(), err)
+	}
+}
+
+func TestRequireKeys(t *testing.T) {
+	conf := NewViperFromMap(map[string]any{
+		"driver": "mysql",
+		"debug":  false,
+		"dsn":    "",
+		"database": map[string]any{
+			"host": "localhost",
+			"user": " ",
+		},
+	})
+
+	tests := []struct {
+		name        string
+		keys        []string
+		wantMissing []string
+	}{
+		{name: "present", keys: []string{"driver", "debug", "database.host"}},
+		{name: "missing", keys: []string{"driver", "timeout", "database.port"}, wantMissing: []string{"timeout", "database.port"}},
+		{name: "empty string", keys: []string{"dsn", "database.user"}, wantMissing: []string{"dsn", "database.user"}},
+		{name: "mixed", keys: []string{"dsn", "driver", "password"}, wantMissing: []string{"dsn", "password"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RequireKeys(conf, tt.keys...)
+			if tt.wantMissing == nil {
+				if err != nil {
+					t.Errorf("RequireKeys() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var missingErr *MissingKeysError
+			if !errors.As(err, &missingErr) {
+				t.Fatalf("RequireKeys() error = %v, want *MissingKeysError", err)
+			}
+			if !slices.Equal(missingErr.Keys, tt.wantMissing) {
+				t.Errorf("missing keys = %v, want %v", missingErr.Keys, tt.wantMissing)
+			}
+			for _, key := range tt.wantMissing {
+				if !strings.Contains(err.Error(), key) {
+					t.Errorf("error %q does not name %q", err, key)
+				}
+			}
+		})
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	path := writeConfig(t, "app.yaml", "log_level: info\n")
+
+	conf := viper.New()
+	conf.SetConfigFile(path)
+	if err := conf.ReadInConfig(); err != nil {
+		t.Fatalf("ReadInConfig() error: %v", err)
+	}
+
+	changed := make(chan string, 10)
+	_, err := WatchConfig(conf, func(snapshot *viper.Viper) {
+		changed <- snapshot.GetString("log_level")
+	}, nil)
+	if err != nil {
+		t.Fatalf("WatchConfig() error: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("log_level: debug\n"), 0o600); err != nil {
+		t.Fatalf("rewrite config: %v", err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case level := <-changed:
+			// Editors and the OS may report several events for one write
+			if level == "debug" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("onChange was not called with the new value")
+		}
+	}
+}
+
+func TestWatchConfig_concurrentReads(t *testing.T) {
+	path := writeConfig(t, "app.yaml", "log_level: info\n")
+
+	conf := viper.New()
+	conf.SetConfigFile(path)
+	if err := conf.ReadInConfig(); err != nil {
+		t.Fatalf("ReadInConfig() error: %v", err)
+	}
+
+	w, err := WatchConfig(conf, nil, nil)
+	if err != nil {
+		t.Fatalf("WatchConfig() error: %v", err)
+	}
+	if got := w.Get().GetString("log_level"); got != "info" {
+		t.Fatalf("initial snapshot log_level = %q, want info", got)
+	}
+
+	// Readers race the reloads below, which -race reports if snapshots are shared with the watcher
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					_ = w.Get().AllSettings()
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	for i, level := range []string{"warn", "error", "debug"} {
+		if err := os.WriteFile(path, []byte("log_level: "+level+"\n"), 0o600); err != nil {
+			t.Fatalf("rewrite %d: %v", i, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for w.Get().GetString("log_level") != "debug" {
+		if time.Now().After(deadline) {
+			t.Fatalf("snapshot log_level = %q, want debug", w.Get().GetString("log_level"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchConfig_malformed(t *testing.T) {
+	path := writeConfig(t, "app.yaml", "log_level: info\n")
+
+	conf := viper.New()
+	conf.SetConfigFile(path)
+	if err := conf.ReadInConfig(); err != nil {
+		t.Fatalf("ReadInConfig() error: %v", err)
+	}
+
+	failed := make(chan error, 10)
+	w, err := WatchConfig(conf, func(snapshot *viper.Viper) {
+		t.Errorf("onChange called with log_level %q for a malformed file", snapshot.GetString("log_level"))
+	}, func(err error) {
+		failed <- err
+	})
+	if err != nil {
+		t.Fatalf("WatchConfig() error: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("log_level: [debug\n"), 0o600); err != nil {
+		t.Fatalf("rewrite config: %v", err)
+	}
+
+	select {
+	case err := <-failed:
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("error %q does not name the file", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("onError was not called for the malformed file")
+	}
+	if got := w.Get().GetString("log_level"); got != "info" {
+		t.Errorf("snapshot log_level = %q, want the previous info", got)
+	}
+}
+
+func TestWatchConfig_notFileBased(t *testing.T) {
+	if _, err := WatchConfig(NewViperFromMap(map[string]any{"a": 1}), func(*viper.Viper) {}, nil); err == nil {
+		t.Error("WatchConfig() on a map-based config should fail")
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	type poolConfig struct {
+		MaxOpenConns int `mapstructure:"max_open_conns"`
+	}
+	type dbConfig struct {
+		Driver string     `mapstructure:"driver"`
+		DSN    string     `mapstructure:"dsn"`
+		Debug  bool       `mapstructure:"debug"`
+		Pool   poolConfig `mapstructure:"pool"`
+	}
+
+	conf := NewViperFromMap(map[string]any{
+		"db": map[string]any{
+			"driver": "postgres",
+			"dsn":    "host=localhost",
+			"debug":  true,
+			"pool":   map[string]any{"max_open_conns": 10},
+		},
+	})
+	want := dbConfig{Driver: "postgres", DSN: "host=localhost", Debug: true, Pool: poolConfig{MaxOpenConns: 10}}
+
+	got, err := UnmarshalKey[dbConfig](conf, "db")
+	if err != nil {
+		t.Fatalf("UnmarshalKey() error: %v", err)
+	}
+	if got != want {
+		t.Errorf("UnmarshalKey() = %+v, want %+v", got, want)
+	}
+
+	all, err := Unmarshal[struct {
+		DB dbConfig `mapstructure:"db"`
+	}](conf)
+	if err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if all.DB != want {
+		t.Errorf("Unmarshal() = %+v, want %+v", all.DB, want)
+	}
+
+	if _, err := UnmarshalKey[dbConfig](NewViperFromMap(map[string]any{"db": map[string]any{"debug": "not a bool"}}), "db"); err == nil {
+		t.Error("UnmarshalKey() with a mistyped field should fail")
+	}
+}
+
+func TestNewViperFromMapOrdered(t *testing.T) {
+	tests := []struct {
+		name       string
+		precedence Precedence
+		wantDSN    string
+	}{
+		{name: "last wins", precedence: LastWins, wantDSN: "override"},
+		{name: "first wins", precedence: FirstWins, wantDSN: "base"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// viper merges into the nested maps it was given, so each case needs its own
+			base := map[string]any{"db": map[string]any{"dsn": "base", "debug": false}}
+			override := map[string]any{"db": map[string]any{"dsn": "override", "driver": "sqlite"}}
+
+			conf := NewViperFromMapOrdered(tt.precedence, base, nil, override)
+
+			if got := conf.GetString("db.dsn"); got != tt.wantDSN {
+				t.Errorf("db.dsn = %q, want %q", got, tt.wantDSN)
+			}
+			// Keys set by only one map survive the deep merge either way
+			if got := conf.GetString("db.driver"); got != "sqlite" {
+				t.Errorf("db.driver = %q, want %q", got, "sqlite")
+			}
+			if !conf.IsSet("db.debug") {
+				t.Error("db.debug was lost in the merge")
+			}
+		})
+	}
+}
@@ -2,11 +2,20 @@ package gormdb
 
 import (
 	"context"
+	"database/sql"
+	"iter"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Query struct {
-	q   map[string]any
-	not map[string]any
+	q     map[string]any
+	not   map[string]any
+	or    []map[string]any
+	conds []clause.Expression
 }
 
 func (q *Query) Not(not map[string]any) *Query {
@@ -14,6 +23,90 @@ func (q *Query) Not(not map[string]any) *Query {
 	return q
 }
 
+// Or adds an alternative group of equality conditions, each call adds one group:
+// Q(a).Or(b).Or(c) matches rows satisfying a OR b OR c. Not conditions still apply to all of them.
+func (q *Query) Or(or map[string]any) *Query {
+	q.or = append(q.or, or)
+	return q
+}
+
+// Gt adds a `column > value` condition, AND-ed with the equality conditions
+func (q *Query) Gt(column string, value any) *Query {
+	return q.cond(clause.Gt{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Gte adds a `column >= value` condition, AND-ed with the equality conditions
+func (q *Query) Gte(column string, value any) *Query {
+	return q.cond(clause.Gte{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Lt adds a `column < value` condition, AND-ed with the equality conditions
+func (q *Query) Lt(column string, value any) *Query {
+	return q.cond(clause.Lt{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Lte adds a `column <= value` condition, AND-ed with the equality conditions
+func (q *Query) Lte(column string, value any) *Query {
+	return q.cond(clause.Lte{Column: clause.Column{Name: column}, Value: value})
+}
+
+// In adds a `column IN (values...)` condition, values being a slice of any element type
+func (q *Query) In(column string, values any) *Query {
+	return q.cond(clause.IN{Column: clause.Column{Name: column}, Values: toAnySlice(values)})
+}
+
+// Like adds a `column LIKE pattern` condition, AND-ed with the equality conditions
+func (q *Query) Like(column string, pattern string) *Query {
+	return q.cond(clause.Like{Column: clause.Column{Name: column}, Value: pattern})
+}
+
+func (q *Query) cond(expr clause.Expression) *Query {
+	q.conds = append(q.conds, expr)
+	return q
+}
+
+// empty reports whether the query has no conditions at all
+func (q *Query) empty() bool {
+	return len(q.q) == 0 && len(q.not) == 0 && len(q.or) == 0 && len(q.conds) == 0
+}
+
+// apply adds the query conditions to db
+func (q *Query) apply(db *gorm.DB) *gorm.DB {
+	if len(q.or) == 0 {
+		return q.where(db).Not(q.not)
+	}
+
+	// Group the OR-ed conditions in parentheses so they don't swallow the Not conditions
+	group := q.where(db.Session(&gorm.Session{NewDB: true}))
+	for _, or := range q.or {
+		group = group.Or(or)
+	}
+
+	return db.Where(group).Not(q.not)
+}
+
+// where adds the equality and comparison conditions to db
+func (q *Query) where(db *gorm.DB) *gorm.DB {
+	db = db.Where(q.q)
+	for _, cond := range q.conds {
+		db = db.Where(cond)
+	}
+	return db
+}
+
+func toAnySlice(values any) []any {
+	rv := reflect.ValueOf(values)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []any{values}
+	}
+
+	result := make([]any, rv.Len())
+	for i := range result {
+		result[i] = rv.Index(i).Interface()
+	}
+	return result
+}
+
 func Q(q map[string]any) *Query {
 	return &Query{q: q}
 }
@@ -23,21 +116,63 @@ type (
 	QueryOptFns []QueryOptFn
 )
 
+// Condition is a raw SQL condition with its placeholder arguments
+type Condition struct {
+	Query string
+	Args  []any
+}
+
 type QueryOpt struct {
 	OmitNotFoundErrFn func(err error) error
+	CursorValue       any
+	CursorColumn      string
+	LockStrength      string
+	VersionColumn     string
+	DistinctColumns   []string
+	Groups            []string
+	Havings           []Condition
+	Joins             []string
 	OrderBy           []string
 	Preloads          []string
+	PreloadConds      []Condition
+	Scopes            []func(*gorm.DB) *gorm.DB
+	Selects           []string
 	TotalCount        int64
+	Timeout           time.Duration
 	PageNumber        int
 	PageSize          int
 	OmitNotFoundErr   bool
+	AllowGlobalDelete bool
+	ContinueOnError   bool
+	Distinct          bool
 	Paginate          bool
+	UseCursor         bool
+	Unscoped          bool
 }
 
+const (
+	DefaultPageNumber    = 1
+	DefaultPageSize      = 50
+	DefaultVersionColumn = "version"
+	DefaultBatchSize     = 100
+)
+
 func NewQueryOpt() *QueryOpt {
 	return &QueryOpt{
-		PageNumber: 1,  // Default to first page
-		PageSize:   50, // Default page size
+		PageNumber:    DefaultPageNumber,
+		PageSize:      DefaultPageSize,
+		VersionColumn: DefaultVersionColumn,
+	}
+}
+
+// normalizePage resets non-positive page number and size to their defaults,
+// so options that bypass Pagination can't cause a negative offset or a division by zero.
+func (c *QueryOpt) normalizePage() {
+	if c.PageNumber < 1 {
+		c.PageNumber = DefaultPageNumber
+	}
+	if c.PageSize < 1 {
+		c.PageSize = DefaultPageSize
 	}
 }
 
@@ -60,6 +195,9 @@ type ListRes[T any] struct {
 	PageSize  int   // Size of each page
 	PageCount int   // Total number of pages
 	Page      int   // Current page number
+	// NextCursor is the cursor column value of the last returned item when Cursor is used
+	// and more records remain; nil when the last page has been reached.
+	NextCursor any
 }
 
 // Pagination enables pagination with specified page number and size
@@ -76,6 +214,24 @@ func Pagination(pageNumber, pageSize int) QueryOptFn {
 	}
 }
 
+// Cursor enables keyset pagination on column: only records whose column value is greater
+// than lastValue are returned, ordered by column and limited to pageSize.
+// Pass a nil lastValue to fetch the first page, then feed ListRes.NextCursor back in.
+// column must be unique and totally ordered, e.g. the primary key: on a column with duplicate
+// values, the records sharing the value of the last record of a page are skipped.
+// Cursor is mutually exclusive with Pagination.
+func Cursor(column string, lastValue any, pageSize int) QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.UseCursor = true
+		c.CursorColumn = column
+		c.CursorValue = lastValue
+		if pageSize > 0 {
+			c.PageSize = pageSize
+		}
+		return c
+	}
+}
+
 // OrderBy sets the order by columns
 func OrderBy(orderBy ...string) QueryOptFn {
 	return func(c *QueryOpt) *QueryOpt {
@@ -84,6 +240,138 @@ func OrderBy(orderBy ...string) QueryOptFn {
 	}
 }
 
+// Select restricts the columns loaded by Get and List, leaving the other fields zero-valued.
+// When combined with Preload, include the columns the relations are keyed on.
+func Select(columns ...string) QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.Selects = columns
+		return c
+	}
+}
+
+// Distinct makes List select distinct rows of columns, which replace the columns of Select,
+// or of the selected projection when no columns are given. PluckColumn plucks distinct values.
+// With Pagination, Total still counts the matching rows before deduplication.
+func Distinct(columns ...string) QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.Distinct = true
+		c.DistinctColumns = columns
+		return c
+	}
+}
+
+// Unscoped includes soft-deleted records in Get, List and Count, and makes Delete a permanent delete
+func Unscoped() QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.Unscoped = true
+		return c
+	}
+}
+
+// Scope adds reusable gorm scopes, e.g. named filters like "only active", to Get, List, Count,
+// Stream and Delete. Scopes are AND-ed with the query and can be given several times.
+//
+// Example:
+//
+//	active := func(db *gorm.DB) *gorm.DB { return db.Where("status = ?", "active") }
+//	users, err := c.List(ctx, Q(nil), Scope(active, forTenant(42)))
+func Scope(fns ...func(*gorm.DB) *gorm.DB) QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.Scopes = append(c.Scopes, fns...)
+		return c
+	}
+}
+
+// Where adds a raw SQL condition with placeholder arguments, AND-ed with the query, for conditions
+// the Query builders can't express. Like Scope it applies to Get, List, Count, Stream and Delete.
+//
+// Example:
+//
+//	users, err := c.List(ctx, Q(map[string]any{"status": "active"}), Where("created_at > ?", since))
+func Where(cond string, args ...any) QueryOptFn {
+	return Scope(func(db *gorm.DB) *gorm.DB {
+		return db.Where(cond, args...)
+	})
+}
+
+// WhereFn adds conditions built by fn on the statement, e.g. when they depend on runtime input.
+// It is Scope with a single function.
+func WhereFn(fn func(*gorm.DB) *gorm.DB) QueryOptFn {
+	return Scope(fn)
+}
+
+// ContinueOnError makes CreateInBatches keep going after a failed batch, keeping the batches
+// that succeed and reporting all the failed ones in a *BatchError. Each batch is created in its own
+// transaction, a savepoint when called inside a Transaction, so a failure doesn't abort the others.
+func ContinueOnError() QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.ContinueOnError = true
+		return c
+	}
+}
+
+// AllowGlobalDelete lets Delete run with a query without conditions, deleting every record
+func AllowGlobalDelete() QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.AllowGlobalDelete = true
+		return c
+	}
+}
+
+// LockForUpdate makes Get issue SELECT ... FOR UPDATE, locking the row until the transaction ends.
+// It is only meaningful inside Transaction, call Get with the ctx the transaction passes in.
+func LockForUpdate() QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.LockStrength = clause.LockingStrengthUpdate
+		return c
+	}
+}
+
+// LockForShare makes Get issue SELECT ... FOR SHARE, blocking concurrent writers until the transaction ends.
+// It is only meaningful inside Transaction, call Get with the ctx the transaction passes in.
+func LockForShare() QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.LockStrength = clause.LockingStrengthShare
+		return c
+	}
+}
+
+// VersionColumn sets the column UpdateByFn uses for optimistic locking, "version" by default.
+// An empty column disables the version check.
+func VersionColumn(column string) QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.VersionColumn = column
+		return c
+	}
+}
+
+// Joins adds raw JOIN clauses to Get and List, e.g. Joins("LEFT JOIN orders ON orders.user_id = users.id"),
+// so conditions can filter on the joined tables' columns
+func Joins(clauses ...string) QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.Joins = append(c.Joins, clauses...)
+		return c
+	}
+}
+
+// Group adds GROUP BY columns to Get and List. Combine it with Select to read aggregates, e.g.
+// Select("status", "count(*) AS total"), Group("status") into a type with Status and Total fields
+// mapped to the same table.
+func Group(columns ...string) QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.Groups = append(c.Groups, columns...)
+		return c
+	}
+}
+
+// Having adds a HAVING condition on the groups, e.g. Having("count(*) > ?", 1)
+func Having(cond string, args ...any) QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.Havings = append(c.Havings, Condition{Query: cond, Args: args})
+		return c
+	}
+}
+
 // Preload sets the relations to preload
 func Preload(preloads ...string) QueryOptFn {
 	return func(c *QueryOpt) *QueryOpt {
@@ -92,6 +380,43 @@ func Preload(preloads ...string) QueryOptFn {
 	}
 }
 
+// PreloadWith preloads relation, which can be nested like "Orders.Items", loading only the
+// records matching the conditions passed to gorm's Preload, e.g. PreloadWith("Orders", "status = ?", "paid").
+func PreloadWith(relation string, args ...any) QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.PreloadConds = append(c.PreloadConds, Condition{Query: relation, Args: args})
+		return c
+	}
+}
+
+// Timeout bounds a single Get, List, Count or Stream call to d, for Stream ranging over the records included
+func Timeout(d time.Duration) QueryOptFn {
+	return func(c *QueryOpt) *QueryOpt {
+		c.Timeout = d
+		return c
+	}
+}
+
+// context derives the context of one operation, applying Timeout if set.
+// The returned cancel func must always be called.
+func (c *QueryOpt) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.Timeout)
+}
+
+// scope applies the options shaping which records a statement targets
+func (c *QueryOpt) scope(db *gorm.DB) *gorm.DB {
+	if c.Unscoped {
+		db = db.Unscoped()
+	}
+	if len(c.Scopes) > 0 {
+		db = db.Scopes(c.Scopes...)
+	}
+	return db
+}
+
 func (opts QueryOptFns) Build() *QueryOpt {
 	c := NewQueryOpt()
 
@@ -116,18 +441,81 @@ type CRUD[T any] interface {
 	// Create supports create one or multiple records
 	// 创建完成后 ID，CreatedAt，UpdatedAt 会回填到 entities 中
 	Create(ctx context.Context, entities ...*T) error
+	// CreateInBatches creates the records batchSize at a time, DefaultBatchSize when batchSize is non-positive,
+	// in one transaction: all the records are created or none, a failed batch being reported in a *BatchError.
+	// With ContinueOnError each batch is created on its own instead. It stops before the next batch
+	// once ctx is done, returning the ctx error.
+	CreateInBatches(ctx context.Context, entities []*T, batchSize int, opts ...QueryOptFn) error
+	// Upsert inserts the entities, updating all non-key columns of rows that conflict on conflictColumns.
+	// An empty conflictColumns targets the primary key.
+	Upsert(ctx context.Context, conflictColumns []string, entities ...*T) error
+	// FirstOrCreate retrieve the first record matches the conditions, or creates it in the same transaction
+	// from the equality conditions and defaults when none does. created reports which happened.
+	// A unique index over the conditions keeps concurrent callers from creating duplicates.
+	FirstOrCreate(ctx context.Context, query *Query, defaults *T) (entity *T, created bool, err error)
 	// Get retrieve one record matches the conditions.
 	Get(ctx context.Context, query *Query, opts ...QueryOptFn) (*T, error)
+	// GetByID retrieve the record whose primary key equals id, whatever the primary key column is named.
+	GetByID(ctx context.Context, id any, opts ...QueryOptFn) (*T, error)
 	// List retrieve all records matches the conditions.
 	List(ctx context.Context, query *Query, opts ...QueryOptFn) (*ListRes[T], error)
+	// ListAll retrieve all records matches the conditions by listing them pageSize at a time.
+	// Give an OrderBy on a unique column so records can't move between pages. It stops before the next
+	// page once ctx is done, returning the ctx error.
+	ListAll(ctx context.Context, query *Query, pageSize int, opts ...QueryOptFn) ([]*T, error)
+	// First retrieves the first n records matching the conditions in ascending orderBy column order,
+	// without counting the total like List.
+	First(ctx context.Context, query *Query, n int, orderBy string) ([]*T, error)
+	// Last retrieves the last n records matching the conditions in ascending orderBy column order,
+	// e.g. the 10 most recent events by created_at, without counting the total like List.
+	Last(ctx context.Context, query *Query, n int, orderBy string) ([]*T, error)
+	// Stream iterates the records matching the conditions one at a time instead of loading them all.
+	// The query runs immediately and holds a connection until the sequence is ranged over to the end,
+	// the loop breaks, or ctx is cancelled; the sequence can be ranged over once.
+	// It honors the options of List but pagination, and fails with Preload, as records aren't loaded in batches.
+	Stream(ctx context.Context, query *Query, opts ...QueryOptFn) (iter.Seq2[*T, error], error)
+	// ExplainGet returns the SQL and args Get would run with the same arguments, without running it.
+	ExplainGet(ctx context.Context, query *Query, opts ...QueryOptFn) (string, []any, error)
+	// ExplainList returns the SQL and args List would run to load the records, without running it.
+	ExplainList(ctx context.Context, query *Query, opts ...QueryOptFn) (string, []any, error)
+	// Count returns the number of records matching the conditions.
+	Count(ctx context.Context, query *Query, opts ...QueryOptFn) (int64, error)
+	// Exists reports whether any record matches the conditions.
+	Exists(ctx context.Context, query *Query) (bool, error)
 	// Update set one or more records match the conditions according to updateParam
-	Update(ctx context.Context, query *Query, uParam map[string]any) error
-	// Delete supports delete one or multiple records
-	Delete(ctx context.Context, query *Query, opts ...QueryOptFn) error
+	// and returns the number of rows affected, 0 if nothing matched.
+	Update(ctx context.Context, query *Query, uParam map[string]any) (int64, error)
+	// UpdateByIDs updates the records whose primary key is in ids according to uParam and returns the
+	// number of rows affected. Long ID lists are split over several statements in one transaction,
+	// which is rolled back if one fails, reporting the failed statement in a *BatchError.
+	UpdateByIDs(ctx context.Context, ids []any, uParam map[string]any) (int64, error)
+	// UpdateReturning updates the first record matching the conditions and returns it refreshed,
+	// using RETURNING where the driver supports it and a re-select otherwise.
+	UpdateReturning(ctx context.Context, query *Query, uParam map[string]any) (*T, error)
+	// Delete supports delete one or multiple records and returns the number of rows affected.
+	// A query without conditions fails with ErrGlobalDelete unless AllowGlobalDelete is given.
+	Delete(ctx context.Context, query *Query, opts ...QueryOptFn) (int64, error)
 
 	// UpdateByFn updates an entity using a function that can contain business logic
-	UpdateByFn(ctx context.Context, query *Query, updateFn func(*T) (bool, error)) error
+	// When T has a version column, a concurrent modification is reported as ErrConcurrentUpdate.
+	UpdateByFn(ctx context.Context, query *Query, updateFn func(*T) (bool, error), opts ...QueryOptFn) error
+	// DeleteByFn loads one record and deletes it in a transaction if shouldDelete returns true.
+	// It returns ErrNotFound when nothing matches, unless OmitNotFoundErr is given.
+	DeleteByFn(ctx context.Context, query *Query, shouldDelete func(*T) (bool, error), opts ...QueryOptFn) error
+	// Sync makes the records matching query equal to desired, matching records by the key selected
+	// by keySel: desired records without a current match are created, matched ones are written over the
+	// current record except for its primary key and creation times, and current records missing from
+	// desired are deleted, all in one transaction.
+	Sync(ctx context.Context, query *Query, desired []*T, keySel func(*T) any) error
 
-	// Transaction executes operations within a database transaction
+	// Transaction executes operations within a database transaction.
+	// CRUD calls made with the ctx passed to f run inside the transaction.
 	Transaction(ctx context.Context, f func(ctx context.Context) error) error
+	// TransactionWithOpts is like Transaction but begins the transaction with opts, e.g. to choose
+	// the isolation level or make it read-only. opts are ignored by nested transactions.
+	TransactionWithOpts(ctx context.Context, opts *sql.TxOptions, f func(ctx context.Context) error) error
+	// TransactionWithRetry is like Transaction but runs the whole transaction again, up to attempts
+	// times with a growing pause in between, when it fails on a deadlock or serialization failure.
+	// Called inside another transaction it runs once, as the outer transaction is already aborted.
+	TransactionWithRetry(ctx context.Context, attempts int, f func(ctx context.Context) error) error
 }
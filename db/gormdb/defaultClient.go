@@ -2,51 +2,323 @@ package gormdb
 
 import (
 	"context"
-
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"slices"
+
+	"github.com/downtoyonder/dry-go/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
 var _ CRUD[struct{}] = (*crud[struct{}])(nil)
 
 type crud[T any] struct {
 	*gorm.DB
+	// tenant is set by NewTenantCRUD to filter every statement by tenant
+	tenant *tenantFilter
+	// defaults and pageSize are set by NewCRUDWithOpts
+	defaults []QueryOptFn
+	pageSize int
 }
 
 func NewCRUD[T any](db *gorm.DB) CRUD[T] {
-	return &crud[T]{db}
+	return &crud[T]{DB: db}
+}
+
+// txCtxKey is the context key holding the *gorm.DB of an ongoing transaction
+type txCtxKey struct{}
+
+// conn returns the transaction bound to ctx by Transaction if there is one,
+// so CRUD calls made inside the transaction callback join it; otherwise r.DB is used.
+// Statements built on it only see the records of the tenant of a tenant CRUD.
+func (r *crud[T]) conn(ctx context.Context) *gorm.DB {
+	db := r.session(ctx)
+	if r.tenant != nil {
+		db = db.Where(r.tenant.cond())
+	}
+	return db
+}
+
+// session is conn without the tenant filter
+func (r *crud[T]) session(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txCtxKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return r.DB.WithContext(ctx)
 }
 
 func (r *crud[T]) Create(ctx context.Context, entities ...*T) error {
-	if err := r.DB.WithContext(ctx).Create(entities).Error; err != nil {
+	if err := r.stamp(ctx, entities...); err != nil {
+		return err
+	}
+
+	if err := r.conn(ctx).Create(entities).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *crud[T]) CreateInBatches(ctx context.Context, entities []*T, batchSize int, opts ...QueryOptFn) error {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	if err := r.stamp(ctx, entities...); err != nil {
 		return err
 	}
 
+	batches := (len(entities) + batchSize - 1) / batchSize
+	if !r.buildOpt(opts...).ContinueOnError {
+		return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+			index := 0
+			for batch := range slices.Chunk(entities, batchSize) {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if err := tx.Create(batch).Error; err != nil {
+					return &BatchError{Failures: []BatchFailure{{Index: index, Err: err}}, Batches: batches}
+				}
+				index++
+			}
+			return nil
+		})
+	}
+
+	batchErr := &BatchError{Batches: batches}
+	index := 0
+	for batch := range slices.Chunk(entities, batchSize) {
+		// Stop at the next batch once ctx is done, still reporting the batches that failed before
+		if err := ctx.Err(); err != nil {
+			if len(batchErr.Failures) > 0 {
+				return errors.Join(err, batchErr)
+			}
+			return err
+		}
+		// A transaction per batch, a savepoint inside a Transaction, so that on databases
+		// like Postgres a failed batch doesn't abort the enclosing transaction
+		err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+			return tx.Create(batch).Error
+		})
+		if err != nil {
+			batchErr.Failures = append(batchErr.Failures, BatchFailure{Index: index, Err: err})
+		}
+		index++
+	}
+
+	if len(batchErr.Failures) > 0 {
+		return batchErr
+	}
 	return nil
 }
 
+func (r *crud[T]) Upsert(ctx context.Context, conflictColumns []string, entities ...*T) error {
+	if err := r.stamp(ctx, entities...); err != nil {
+		return err
+	}
+
+	columns := make([]clause.Column, len(conflictColumns))
+	for i, col := range conflictColumns {
+		columns[i] = clause.Column{Name: col}
+	}
+
+	onConflict := clause.OnConflict{Columns: columns, UpdateAll: true}
+	if r.tenant != nil {
+		var err error
+		if onConflict, err = r.tenantOnConflict(columns); err != nil {
+			return err
+		}
+	}
+
+	if err := r.conn(ctx).Clauses(onConflict).Create(entities).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *crud[T]) FirstOrCreate(ctx context.Context, query *Query, defaults *T) (*T, bool, error) {
+	var (
+		entity  = new(T)
+		created bool
+	)
+
+	o := r.buildOpt()
+
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		db := query.apply(o.scope(tx))
+		if defaults != nil {
+			db = db.Attrs(defaults)
+		}
+
+		// gorm reports no affected rows when the record was found instead of created
+		res := db.FirstOrCreate(entity)
+		if res.Error != nil {
+			return res.Error
+		}
+		created = res.RowsAffected > 0
+
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return entity, created, nil
+}
+
 func (r *crud[T]) Get(ctx context.Context, query *Query, opts ...QueryOptFn) (*T, error) {
 	result := new(T)
-	o := BuildOpt(opts...)
+	o := r.buildOpt(opts...)
+
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	if err := r.getQuery(r.conn(ctx), query, o).First(result).Error; err != nil {
+		err = translateNotFound(err)
+		if o.OmitNotFoundErr && errors.Is(err, ErrNotFound) {
+			return nil, o.OmitNotFoundErrFn(err)
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getQuery applies the query and options of Get to db
+func (r *crud[T]) getQuery(db *gorm.DB, query *Query, o *QueryOpt) *gorm.DB {
+	db = filterQuery(db, query, o)
+
+	if o.LockStrength != "" {
+		db = db.Clauses(clause.Locking{Strength: o.LockStrength})
+	}
+
+	return projectQuery(db, o)
+}
+
+// filterQuery applies the query and the options choosing the records of Get, List and Stream to db
+func filterQuery(db *gorm.DB, query *Query, o *QueryOpt) *gorm.DB {
+	db = query.apply(o.scope(db))
+
+	for _, join := range o.Joins {
+		db = db.Joins(join)
+	}
+
+	for _, group := range o.Groups {
+		db = db.Group(group)
+	}
+
+	for _, having := range o.Havings {
+		db = db.Having(having.Query, having.Args...)
+	}
+
+	return db
+}
+
+// projectQuery applies the options shaping the records loaded by Get, List and Stream to db
+func projectQuery(db *gorm.DB, o *QueryOpt) *gorm.DB {
+	if len(o.Selects) > 0 {
+		db = db.Select(o.Selects)
+	}
 
-	db := r.DB.WithContext(ctx).Where(query.q).Not(query.not)
+	if o.Distinct {
+		db = db.Distinct(toAnySlice(o.DistinctColumns)...)
+	}
 
 	// Apply preloads if specified
 	for _, preload := range o.Preloads {
 		db = db.Preload(preload)
 	}
 
-	if err := db.First(result).Error; err != nil && o.OmitNotFoundErr {
-		return nil, o.OmitNotFoundErrFn(err)
+	for _, preload := range o.PreloadConds {
+		db = db.Preload(preload.Query, preload.Args...)
 	}
 
-	return result, nil
+	return db
+}
+
+// ExplainGet returns the SQL and args Get would run, without running it.
+func (r *crud[T]) ExplainGet(ctx context.Context, query *Query, opts ...QueryOptFn) (string, []any, error) {
+	o := r.buildOpt(opts...)
+
+	stmt := r.getQuery(r.dryRun(ctx), query, o).First(new(T))
+	if stmt.Error != nil {
+		return "", nil, stmt.Error
+	}
+
+	return stmt.Statement.SQL.String(), stmt.Statement.Vars, nil
+}
+
+// dryRun returns a connection that builds statements without executing them
+func (r *crud[T]) dryRun(ctx context.Context) *gorm.DB {
+	return r.conn(ctx).Session(&gorm.Session{DryRun: true})
+}
+
+func (r *crud[T]) GetByID(ctx context.Context, id any, opts ...QueryOptFn) (*T, error) {
+	pk, err := r.primaryKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Get(ctx, Q(map[string]any{pk: id}), opts...)
 }
 
 func (r *crud[T]) List(ctx context.Context, query *Query, opts ...QueryOptFn) (*ListRes[T], error) {
 	results := make([]*T, 0)
-	o := BuildOpt(opts...)
+	o := r.buildOpt(opts...)
+	o.normalizePage()
+
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	db, err := r.listQuery(r.conn(ctx), query, o)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Find(&results).Error; err != nil {
+		return nil, err
+	}
+
+	var nextCursor any
+	if o.UseCursor && len(results) > o.PageSize {
+		results = results[:o.PageSize]
+
+		if nextCursor, err = r.columnValue(ctx, results[len(results)-1], o.CursorColumn); err != nil {
+			return nil, err
+		}
+	}
+
+	// Calculate total pages
+	pageCount := int(o.TotalCount / int64(o.PageSize))
+	if o.TotalCount%int64(o.PageSize) > 0 {
+		pageCount++
+	}
+	return &ListRes[T]{Items: results, Total: o.TotalCount, PageSize: o.PageSize, PageCount: pageCount, Page: o.PageNumber, NextCursor: nextCursor}, nil
+}
 
-	db := r.DB.WithContext(ctx).Where(query.q).Not(query.not)
+// listQuery applies the query and options of List to db, counting the total records when paginating
+func (r *crud[T]) listQuery(db *gorm.DB, query *Query, o *QueryOpt) (*gorm.DB, error) {
+	if o.Paginate && o.UseCursor {
+		return nil, errors.New("gormdb: Pagination and Cursor options are mutually exclusive")
+	}
+
+	db = filterQuery(db, query, o)
+
+	// Apply keyset pagination, its ordering takes precedence over OrderBy
+	if o.UseCursor {
+		cursorCol := clause.Column{Name: o.CursorColumn}
+		if o.CursorValue != nil {
+			db = db.Where(clause.Gt{Column: cursorCol, Value: o.CursorValue})
+		}
+		// Fetch one extra record to know whether another page exists
+		db = db.Order(clause.OrderByColumn{Column: cursorCol}).Limit(o.PageSize + 1)
+	}
 
 	// Apply sorting if specified
 	for _, orderBy := range o.OrderBy {
@@ -64,54 +336,344 @@ func (r *crud[T]) List(ctx context.Context, query *Query, opts ...QueryOptFn) (*
 		db = db.Offset(offset).Limit(o.PageSize)
 	}
 
-	// Apply preloads if specified
-	for _, preload := range o.Preloads {
-		db = db.Preload(preload)
+	// Applied after counting so the projection doesn't alter the COUNT query
+	return projectQuery(db, o), nil
+}
+
+// ExplainList returns the SQL and args List would run to load the records, without running it.
+// The COUNT query of Pagination isn't included.
+func (r *crud[T]) ExplainList(ctx context.Context, query *Query, opts ...QueryOptFn) (string, []any, error) {
+	o := r.buildOpt(opts...)
+	o.normalizePage()
+
+	db, err := r.listQuery(r.dryRun(ctx), query, o)
+	if err != nil {
+		return "", nil, err
+	}
+	// A dry run keeps the SQL of the pagination COUNT in the shared statement, which Find would reuse
+	db.Statement.SQL.Reset()
+	db.Statement.Vars = nil
+
+	stmt := db.Find(&[]*T{})
+	if stmt.Error != nil {
+		return "", nil, stmt.Error
 	}
 
+	return stmt.Statement.SQL.String(), stmt.Statement.Vars, nil
+}
+
+func (r *crud[T]) ListAll(ctx context.Context, query *Query, pageSize int, opts ...QueryOptFn) ([]*T, error) {
+	var results []*T
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		res, err := r.List(ctx, query, append(slices.Clone(opts), Pagination(page, pageSize))...)
+		if err != nil {
+			return nil, err
+		}
+
+		if results == nil {
+			results = make([]*T, 0, res.Total)
+		}
+		results = append(results, res.Items...)
+
+		if res.Page >= res.PageCount {
+			return results, nil
+		}
+	}
+}
+
+func (r *crud[T]) First(ctx context.Context, query *Query, n int, orderBy string) ([]*T, error) {
+	return r.edge(ctx, query, n, orderBy, false)
+}
+
+func (r *crud[T]) Last(ctx context.Context, query *Query, n int, orderBy string) ([]*T, error) {
+	results, err := r.edge(ctx, query, n, orderBy, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Loaded in descending order to LIMIT from the end, returned in ascending order like First
+	slices.Reverse(results)
+	return results, nil
+}
+
+// edge loads the first n records ordered by the orderBy column, descending when desc
+func (r *crud[T]) edge(ctx context.Context, query *Query, n int, orderBy string, desc bool) ([]*T, error) {
+	results := make([]*T, 0)
+	if n < 1 {
+		return results, nil
+	}
+
+	o := r.buildOpt()
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	db := query.apply(o.scope(r.conn(ctx))).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: orderBy}, Desc: desc}).
+		Limit(n)
 	if err := db.Find(&results).Error; err != nil {
 		return nil, err
 	}
 
-	// Calculate total pages
-	pageCount := int(o.TotalCount / int64(o.PageSize))
-	if o.TotalCount%int64(o.PageSize) > 0 {
-		pageCount++
+	return results, nil
+}
+
+// schema returns the parsed gorm schema of T
+func (r *crud[T]) schema() (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: r.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+
+	return stmt.Schema, nil
+}
+
+// columnValue reads the value of the given column (db name or struct field name) from entity
+func (r *crud[T]) columnValue(ctx context.Context, entity *T, column string) (any, error) {
+	s, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+
+	field := s.LookUpField(column)
+	if field == nil {
+		return nil, fmt.Errorf("gormdb: column %q not found in %s", column, s.Name)
+	}
+
+	value, _ := field.ValueOf(ctx, reflect.ValueOf(entity))
+	return value, nil
+}
+
+// primaryKey returns the db column name of T's primary key
+func (r *crud[T]) primaryKey() (string, error) {
+	s, err := r.schema()
+	if err != nil {
+		return "", err
+	}
+
+	if s.PrioritizedPrimaryField == nil {
+		return "", fmt.Errorf("gormdb: %s has no single primary key", s.Name)
+	}
+
+	return s.PrioritizedPrimaryField.DBName, nil
+}
+
+func (r *crud[T]) Stream(ctx context.Context, query *Query, opts ...QueryOptFn) (iter.Seq2[*T, error], error) {
+	o := r.buildOpt(opts...)
+	// Rows are scanned one by one, so there is no batch to load the associations for
+	if len(o.Preloads) > 0 || len(o.PreloadConds) > 0 {
+		return nil, errors.New("gormdb: Stream can't preload associations")
+	}
+
+	// The timeout covers the iteration too, so it is only released once the sequence is done
+	ctx, cancel := o.context(ctx)
+
+	db := filterQuery(r.conn(ctx), query, o).Model(new(T))
+
+	for _, orderBy := range o.OrderBy {
+		db = db.Order(orderBy)
+	}
+
+	rows, err := projectQuery(db, o).Rows()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return func(yield func(*T, error) bool) {
+		defer cancel()
+		defer rows.Close()
+
+		for rows.Next() {
+			item := new(T)
+			if err := db.ScanRows(rows, item); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+
+		// Also reports the context error when ctx is cancelled mid-iteration
+		if err := rows.Err(); err != nil {
+			yield(nil, err)
+		}
+	}, nil
+}
+
+func (r *crud[T]) Count(ctx context.Context, query *Query, opts ...QueryOptFn) (int64, error) {
+	var count int64
+	o := r.buildOpt(opts...)
+
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	if err := query.apply(o.scope(r.conn(ctx)).Model(new(T))).Count(&count).Error; err != nil {
+		return 0, err
 	}
-	return &ListRes[T]{Items: results, Total: o.TotalCount, PageSize: o.PageSize, PageCount: pageCount, Page: o.PageNumber}, nil
+
+	return count, nil
 }
 
-func (r *crud[T]) Update(ctx context.Context, query *Query, uParam map[string]any) error {
+func (r *crud[T]) Exists(ctx context.Context, query *Query) (bool, error) {
+	var found int
+	o := r.buildOpt()
+
+	// SELECT 1 ... LIMIT 1 stops at the first match instead of counting every row
+	if err := query.apply(o.scope(r.conn(ctx)).Model(new(T)).Select("1")).Limit(1).Scan(&found).Error; err != nil {
+		return false, err
+	}
+
+	return found == 1, nil
+}
+
+func (r *crud[T]) Update(ctx context.Context, query *Query, uParam map[string]any) (int64, error) {
+	if err := r.checkUpdate(uParam); err != nil {
+		return 0, err
+	}
+
+	o := r.buildOpt()
 	updatedEntity := new(T)
 	// 创建完成后 ID，CreatedAt，UpdatedAt 会回填到 updatedEntity 中吗？待确认
-	if err := r.DB.WithContext(ctx).Model(updatedEntity).Where(query.q).Not(query.not).Updates(uParam).Error; err != nil {
-		return err
+	res := query.apply(o.scope(r.conn(ctx)).Model(updatedEntity)).Updates(uParam)
+	if res.Error != nil {
+		return 0, translateNotFound(res.Error)
 	}
 
-	return nil
+	return res.RowsAffected, nil
+}
+
+// idChunkSize keeps the IN lists of UpdateByIDs and GetByIDs well under the placeholder limits of the drivers
+const idChunkSize = 500
+
+func (r *crud[T]) UpdateByIDs(ctx context.Context, ids []any, uParam map[string]any) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if err := r.checkUpdate(uParam); err != nil {
+		return 0, err
+	}
+
+	pk, err := r.primaryKey()
+	if err != nil {
+		return 0, err
+	}
+
+	o := r.buildOpt()
+
+	var affected int64
+	err = r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		batches := (len(ids) + idChunkSize - 1) / idChunkSize
+		index := 0
+		for chunk := range slices.Chunk(ids, idChunkSize) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			res := o.scope(tx).Model(new(T)).Where(clause.IN{Column: clause.Column{Name: pk}, Values: chunk}).Updates(uParam)
+			if res.Error != nil {
+				return &BatchError{Failures: []BatchFailure{{Index: index, Err: res.Error}}, Batches: batches}
+			}
+			affected += res.RowsAffected
+			index++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+func (r *crud[T]) UpdateReturning(ctx context.Context, query *Query, uParam map[string]any) (*T, error) {
+	if err := r.checkUpdate(uParam); err != nil {
+		return nil, err
+	}
+
+	updatedEntity := new(T)
+	o := r.buildOpt()
+
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := query.apply(o.scope(tx)).First(updatedEntity).Error; err != nil {
+			return translateNotFound(err)
+		}
+
+		// Updating through the loaded model targets its primary key, so the refreshed row is
+		// the updated one even if uParam changes the columns the query filters on
+		returning := slices.Contains(tx.Callback().Update().Clauses, "RETURNING")
+		db := o.scope(tx).Model(updatedEntity)
+		if returning {
+			db = db.Clauses(clause.Returning{})
+		}
+		if err := db.Updates(uParam).Error; err != nil {
+			return err
+		}
+
+		if returning {
+			return nil
+		}
+		return o.scope(tx).First(updatedEntity).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedEntity, nil
 }
 
-func (r *crud[T]) Delete(ctx context.Context, query *Query, opts ...QueryOptFn) error {
-	o := BuildOpt(opts...)
+func (r *crud[T]) Delete(ctx context.Context, query *Query, opts ...QueryOptFn) (int64, error) {
+	o := r.buildOpt(opts...)
 
 	var t T
 
-	if err := r.DB.WithContext(ctx).Where(query.q).Not(query.not).Delete(&t).Error; err != nil && o.OmitNotFoundErr {
-		return o.OmitNotFoundErrFn(err)
+	db := o.scope(r.conn(ctx))
+	// Scopes usually add conditions, gorm still refuses a DELETE when they don't
+	if query.empty() && len(o.Scopes) == 0 {
+		if !o.AllowGlobalDelete {
+			return 0, ErrGlobalDelete
+		}
+		db = db.Session(&gorm.Session{AllowGlobalUpdate: true})
 	}
 
-	return nil
+	res := query.apply(db).Delete(&t)
+	if err := translateNotFound(res.Error); err != nil {
+		if o.OmitNotFoundErr && errors.Is(err, ErrNotFound) {
+			return 0, o.OmitNotFoundErrFn(err)
+		}
+		return 0, err
+	}
+
+	return res.RowsAffected, nil
 }
 
 // 如此，repo 层就没有业务逻辑代码了，updateFn 虽然参数只有 *T，
 // 不过在业务层可以临时闭包函数的形式捕获业务层变量，以更新 *T
 // 这种方式称做 updateFn pattern
-func (r *crud[T]) UpdateByFn(ctx context.Context, query *Query, updateFn func(*T) (bool, error)) error {
-	return r.DB.Transaction(func(tx *gorm.DB) error {
+//
+// 如果 T 有版本字段（默认 version 列，可用 VersionColumn 配置），保存时会带上 WHERE version = ? 并自增版本，
+// 版本不匹配时返回 ErrConcurrentUpdate
+func (r *crud[T]) UpdateByFn(ctx context.Context, query *Query, updateFn func(*T) (bool, error), opts ...QueryOptFn) error {
+	o := r.buildOpt(opts...)
+
+	s, err := r.schema()
+	if err != nil {
+		return err
+	}
+
+	var versionField *schema.Field
+	if o.VersionColumn != "" {
+		versionField = s.LookUpField(o.VersionColumn)
+	}
+
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
 		updatedEntity := new(T)
 
-		if err := tx.WithContext(ctx).Where(query.q).Not(query.not).First(updatedEntity).Error; err != nil {
-			return err
+		if err := query.apply(o.scope(tx)).First(updatedEntity).Error; err != nil {
+			return translateNotFound(err)
 		}
 
 		updated, err := updateFn(updatedEntity)
@@ -123,7 +685,16 @@ func (r *crud[T]) UpdateByFn(ctx context.Context, query *Query, updateFn func(*T
 			return nil
 		}
 
-		if err := tx.WithContext(ctx).Save(updatedEntity).Error; err != nil {
+		// updateFn must not move the record to another tenant
+		if err := r.stamp(ctx, updatedEntity); err != nil {
+			return err
+		}
+
+		if versionField != nil {
+			return saveVersioned(ctx, o.scope(tx), versionField, updatedEntity)
+		}
+
+		if err := o.scope(tx).Save(updatedEntity).Error; err != nil {
 			return err
 		}
 
@@ -131,9 +702,187 @@ func (r *crud[T]) UpdateByFn(ctx context.Context, query *Query, updateFn func(*T
 	})
 }
 
-// Implementation of transaction for CRUD operations
-func (r *crud[T]) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
-	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		return fn(ctx)
+// DeleteByFn 是 UpdateByFn 的删除版本：在事务中加载实体，由 shouldDelete 根据实体状态决定是否删除
+func (r *crud[T]) DeleteByFn(ctx context.Context, query *Query, shouldDelete func(*T) (bool, error), opts ...QueryOptFn) error {
+	o := r.buildOpt(opts...)
+
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		entity := new(T)
+
+		if err := translateNotFound(query.apply(o.scope(tx)).First(entity).Error); err != nil {
+			if o.OmitNotFoundErr && errors.Is(err, ErrNotFound) {
+				return o.OmitNotFoundErrFn(err)
+			}
+			return err
+		}
+
+		remove, err := shouldDelete(entity)
+		if err != nil {
+			return err
+		}
+
+		if !remove {
+			return nil
+		}
+
+		return o.scope(tx).Delete(entity).Error
 	})
 }
+
+// Sync 同步子集合，例如替换用户的所有标签：按 keySel 的键比较当前记录与 desired，
+// 新增的创建，重叠的以 desired 覆盖（沿用当前记录的主键和创建时间），缺失的删除，当前记录中键重复的只保留一条。keySel 返回的键须可比较。
+func (r *crud[T]) Sync(ctx context.Context, query *Query, desired []*T, keySel func(*T) any) error {
+	s, err := r.schema()
+	if err != nil {
+		return err
+	}
+	pk := s.PrioritizedPrimaryField
+	if pk == nil {
+		return fmt.Errorf("gormdb: %s has no single primary key", s.Name)
+	}
+
+	// The primary key and creation times of matched records are kept, the other columns synced
+	preserved := []*schema.Field{pk}
+	preservedColumns := []string{pk.DBName}
+	for _, field := range s.Fields {
+		if field.AutoCreateTime > 0 && field.DBName != "" {
+			preserved = append(preserved, field)
+			preservedColumns = append(preservedColumns, field.DBName)
+		}
+	}
+
+	desiredByKey := make(map[any]*T, len(desired))
+	desiredKeys := make([]any, 0, len(desired))
+	for _, entity := range desired {
+		key := keySel(entity)
+		if _, ok := desiredByKey[key]; ok {
+			return fmt.Errorf("gormdb: sync: duplicate key %v", key)
+		}
+		desiredByKey[key] = entity
+		desiredKeys = append(desiredKeys, key)
+	}
+
+	if err := r.stamp(ctx, desired...); err != nil {
+		return err
+	}
+
+	o := r.buildOpt()
+
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var current []*T
+		if err := query.apply(o.scope(tx)).Find(&current).Error; err != nil {
+			return err
+		}
+
+		// Records sharing a key with an earlier one are deleted, so one record remains per key
+		var ids []any
+		currentByKey := make(map[any]*T, len(current))
+		currentKeys := make([]any, 0, len(current))
+		for _, entity := range current {
+			key := keySel(entity)
+			if _, ok := currentByKey[key]; ok {
+				id, _ := pk.ValueOf(ctx, reflect.ValueOf(entity))
+				ids = append(ids, id)
+				continue
+			}
+			currentByKey[key] = entity
+			currentKeys = append(currentKeys, key)
+		}
+
+		added, overlapped, deleted := utils.SetCmpSet(currentKeys, desiredKeys)
+
+		// Walk desired rather than the sets to create and save in a stable order
+		for _, key := range desiredKeys {
+			entity := desiredByKey[key]
+			switch {
+			case added.Contain(key):
+				if err := tx.Create(entity).Error; err != nil {
+					return err
+				}
+			case overlapped.Contain(key):
+				currentValue, entityValue := reflect.ValueOf(currentByKey[key]), reflect.ValueOf(entity)
+				for _, field := range preserved {
+					value, _ := field.ValueOf(ctx, currentValue)
+					if err := field.Set(ctx, entityValue, value); err != nil {
+						return err
+					}
+				}
+				// Select("*") writes zero values too, so the record ends up equal to entity
+				if err := o.scope(tx).Model(entity).Select("*").Omit(preservedColumns...).Updates(entity).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, key := range currentKeys {
+			if deleted.Contain(key) {
+				id, _ := pk.ValueOf(ctx, reflect.ValueOf(currentByKey[key]))
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		return o.scope(tx).Where(clause.IN{Column: clause.Column{Name: pk.DBName}, Values: ids}).Delete(new(T)).Error
+	})
+}
+
+// saveVersioned saves entity only if its version column still holds the loaded value, bumping it by one
+func saveVersioned[T any](ctx context.Context, tx *gorm.DB, versionField *schema.Field, entity *T) error {
+	rv := reflect.ValueOf(entity)
+	current, _ := versionField.ValueOf(ctx, rv)
+
+	next, err := bumpVersion(current)
+	if err != nil {
+		return fmt.Errorf("gormdb: version column %q: %w", versionField.DBName, err)
+	}
+	if err := versionField.Set(ctx, rv, next); err != nil {
+		return err
+	}
+
+	// Explicit Select("*") keeps Save from falling back to an insert when no row matches
+	res := tx.Select("*").Where(clause.Eq{Column: clause.Column{Name: versionField.DBName}, Value: current}).Save(entity)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConcurrentUpdate
+	}
+
+	return nil
+}
+
+func bumpVersion(v any) (any, error) {
+	rv := reflect.ValueOf(v)
+	next := reflect.New(rv.Type()).Elem()
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		next.SetInt(rv.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		next.SetUint(rv.Uint() + 1)
+	default:
+		return nil, fmt.Errorf("unsupported version type %s", rv.Type())
+	}
+
+	return next.Interface(), nil
+}
+
+// Implementation of transaction for CRUD operations.
+// The transaction is carried by the ctx passed to fn, so any CRUD (of any entity type)
+// called with that ctx runs inside it. Nested calls use savepoints.
+func (r *crud[T]) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.TransactionWithOpts(ctx, nil, fn)
+}
+
+func (r *crud[T]) TransactionWithOpts(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	var txOpts []*sql.TxOptions
+	if opts != nil {
+		txOpts = append(txOpts, opts)
+	}
+
+	return r.session(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txCtxKey{}, tx))
+	}, txOpts...)
+}
@@ -0,0 +1,1882 @@
+package gormdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type testUser struct {
+	Name   string
+	Status string
+	ID     uint `gorm:"primaryKey"`
+	Age    int
+}
+
+// newTestDB opens a file-backed sqlite database in a temp dir and migrates the given models
+func newTestDB(t *testing.T, models ...any) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	return db
+}
+
+// seedUsers creates n users named user-1..user-n with ages 1..n
+func seedUsers(t *testing.T, c CRUD[testUser], n int) []*testUser {
+	t.Helper()
+
+	users := make([]*testUser, n)
+	for i := range users {
+		users[i] = &testUser{Name: "user-" + strconv.Itoa(i+1), Age: i + 1, Status: "active"}
+	}
+	if err := c.Create(context.Background(), users...); err != nil {
+		t.Fatalf("seed users: %v", err)
+	}
+
+	return users
+}
+
+func userIDs(users []*testUser) []uint {
+	ids := make([]uint, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
+
+func TestList_cursor(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 25)
+
+	var (
+		seen   []uint
+		cursor any
+		pages  int
+	)
+	for {
+		res, err := c.List(ctx, Q(nil), Cursor("id", cursor, 10))
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		pages++
+
+		for _, u := range res.Items {
+			seen = append(seen, u.ID)
+		}
+
+		// Insert a row mid-iteration; keyset pagination must neither skip nor duplicate rows
+		if pages == 1 {
+			if err := c.Create(ctx, &testUser{Name: "late", Age: 99}); err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+		}
+
+		if res.NextCursor == nil {
+			break
+		}
+		cursor = res.NextCursor
+	}
+
+	if pages != 3 {
+		t.Errorf("pages = %d, want 3", pages)
+	}
+	if len(seen) != 26 {
+		t.Fatalf("iterated %d rows, want 26", len(seen))
+	}
+	for i, id := range seen {
+		if id != uint(i+1) {
+			t.Fatalf("row %d has id %d, want %d", i, id, i+1)
+		}
+	}
+}
+
+func TestList_cursorWithPagination(t *testing.T) {
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+
+	if _, err := c.List(context.Background(), Q(nil), Cursor("id", nil, 10), Pagination(1, 10)); err == nil {
+		t.Error("List() with both Cursor and Pagination should fail")
+	}
+}
+
+func TestGet_errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("db error", func(t *testing.T) {
+		// No migration, so the table does not exist
+		c := NewCRUD[testUser](newTestDB(t))
+
+		if _, err := c.Get(ctx, Q(map[string]any{"id": 1})); err == nil {
+			t.Error("Get() on a missing table should return an error")
+		}
+		// A real error must not be swallowed by the not-found handler
+		_, err := c.Get(ctx, Q(map[string]any{"id": 1}), OmitNotFoundErr(func(error) error { return nil }))
+		if err == nil {
+			t.Error("Get() with OmitNotFoundErr should still return non not-found errors")
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+
+		_, err := c.Get(ctx, Q(map[string]any{"id": 1}))
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() error = %v, want %v", err, ErrNotFound)
+		}
+		// Callers already matching the gorm error keep working
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("Get() error = %v, want it to match %v too", err, gorm.ErrRecordNotFound)
+		}
+
+		var seen error
+		_, _ = c.Get(ctx, Q(map[string]any{"id": 1}), OmitNotFoundErr(func(err error) error {
+			seen = err
+			return nil
+		}))
+		if !errors.Is(seen, ErrNotFound) {
+			t.Errorf("OmitNotFoundErr handler got %v, want %v", seen, ErrNotFound)
+		}
+
+		err = c.UpdateByFn(ctx, Q(map[string]any{"id": 1}), func(*testUser) (bool, error) { return true, nil })
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateByFn() error = %v, want %v", err, ErrNotFound)
+		}
+
+		got, err := c.Get(ctx, Q(map[string]any{"id": 1}), OmitNotFoundErr(func(error) error { return nil }))
+		if err != nil || got != nil {
+			t.Errorf("Get() with OmitNotFoundErr = (%v, %v), want (nil, nil)", got, err)
+		}
+	})
+}
+
+func TestTransaction_rollback(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 1)
+
+	errAbort := errors.New("abort")
+	err := c.Transaction(ctx, func(ctx context.Context) error {
+		if err := c.Create(ctx, &testUser{Name: "in-tx"}); err != nil {
+			return err
+		}
+		if _, err := c.Update(ctx, Q(map[string]any{"id": 1}), map[string]any{"name": "renamed"}); err != nil {
+			return err
+		}
+		// Reads inside the transaction see its own writes
+		if _, err := c.Get(ctx, Q(map[string]any{"name": "in-tx"})); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Transaction() error = %v, want %v", err, errAbort)
+	}
+
+	res, err := c.List(ctx, Q(nil))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(res.Items) != 1 || res.Items[0].Name != "user-1" {
+		t.Errorf("after rollback got %+v, want only the untouched user-1", res.Items)
+	}
+}
+
+func TestTransaction_commit(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+
+	err := c.Transaction(ctx, func(ctx context.Context) error {
+		return c.Create(ctx, &testUser{Name: "a"}, &testUser{Name: "b"})
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error: %v", err)
+	}
+
+	res, err := c.List(ctx, Q(nil))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(res.Items) != 2 {
+		t.Errorf("after commit got %d rows, want 2", len(res.Items))
+	}
+}
+
+// txOptsRecorder records the options transactions are begun with
+type txOptsRecorder struct {
+	*sql.DB
+	opts []*sql.TxOptions
+}
+
+func (r *txOptsRecorder) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	r.opts = append(r.opts, opts)
+	return r.DB.BeginTx(ctx, opts)
+}
+
+func TestTransactionWithOpts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testUser{})
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("DB() error: %v", err)
+	}
+	recorder := &txOptsRecorder{DB: sqlDB}
+	db.ConnPool, db.Statement.ConnPool = recorder, recorder
+
+	c := NewCRUD[testUser](db)
+	serializable := &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}
+
+	if err := c.TransactionWithOpts(ctx, serializable, func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("TransactionWithOpts() error: %v", err)
+	}
+	if err := c.Transaction(ctx, func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("Transaction() error: %v", err)
+	}
+
+	if len(recorder.opts) != 2 || recorder.opts[0] != serializable || recorder.opts[1] != nil {
+		t.Errorf("transactions begun with %v, want [%v <nil>]", recorder.opts, serializable)
+	}
+}
+
+func TestTransactionWithOpts_readOnly(t *testing.T) {
+	// sqlite ignores the transaction options, so this needs a real postgres
+	dsn := os.Getenv("DRY_GO_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("DRY_GO_POSTGRES_DSN not set")
+	}
+
+	ctx := context.Background()
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	if err := db.AutoMigrate(&testUser{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	c := NewCRUD[testUser](db)
+
+	err = c.TransactionWithOpts(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context) error {
+		return c.Create(ctx, &testUser{Name: "read-only"})
+	})
+	if err == nil {
+		t.Error("Create() in a read-only transaction should fail")
+	}
+}
+
+func TestList_paginationBounds(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 60)
+
+	zeroPageSize := func(o *QueryOpt) *QueryOpt {
+		o.PageSize = 0
+		return o
+	}
+	negativePage := func(o *QueryOpt) *QueryOpt {
+		o.PageNumber = -3
+		return o
+	}
+
+	tests := []struct {
+		name      string
+		opts      []QueryOptFn
+		wantPage  int
+		wantSize  int
+		wantItems int
+	}{
+		{name: "PageSize=0", opts: []QueryOptFn{Pagination(2, 0)}, wantPage: 2, wantSize: DefaultPageSize, wantItems: 10},
+		{name: "PageNumber=0", opts: []QueryOptFn{Pagination(0, 25)}, wantPage: 1, wantSize: 25, wantItems: 25},
+		{name: "PageSize overridden to 0", opts: []QueryOptFn{Pagination(1, 10), zeroPageSize}, wantPage: 1, wantSize: DefaultPageSize, wantItems: 50},
+		{name: "negative PageNumber", opts: []QueryOptFn{Pagination(1, 10), negativePage}, wantPage: 1, wantSize: 10, wantItems: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.List(ctx, Q(nil), tt.opts...)
+			if err != nil {
+				t.Fatalf("List() error: %v", err)
+			}
+			if res.Page != tt.wantPage || res.PageSize != tt.wantSize || len(res.Items) != tt.wantItems {
+				t.Errorf("List() page=%d size=%d items=%d, want page=%d size=%d items=%d",
+					res.Page, res.PageSize, len(res.Items), tt.wantPage, tt.wantSize, tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestListAll(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+
+	got, err := c.ListAll(ctx, Q(nil), 10)
+	if err != nil {
+		t.Fatalf("ListAll(empty) error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ListAll(empty) returned %d users, want 0", len(got))
+	}
+
+	users := seedUsers(t, c, 25)
+
+	got, err = c.ListAll(ctx, Q(nil), 10, OrderBy("id"))
+	if err != nil {
+		t.Fatalf("ListAll() error: %v", err)
+	}
+	if want := userIDs(users); !slices.Equal(userIDs(got), want) {
+		t.Errorf("ListAll() ids = %v, want %v", userIDs(got), want)
+	}
+
+	got, err = c.ListAll(ctx, Q(nil).Gt("age", 20), 2, OrderBy("id"))
+	if err != nil {
+		t.Fatalf("ListAll(filtered) error: %v", err)
+	}
+	if want := userIDs(users[20:]); !slices.Equal(userIDs(got), want) {
+		t.Errorf("ListAll(filtered) ids = %v, want %v", userIDs(got), want)
+	}
+
+	if _, err := c.ListAll(ctx, Q(nil), 10, Cursor("id", nil, 10)); err == nil {
+		t.Error("ListAll() should return the error of a failing page")
+	}
+}
+
+type testAccount struct {
+	Email string `gorm:"uniqueIndex"`
+	Name  string
+	ID    uint `gorm:"primaryKey"`
+}
+
+func TestFirstOrCreate(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testAccount](newTestDB(t, &testAccount{}))
+
+	existing := &testAccount{Email: "a@example.com", Name: "Alice"}
+	if err := c.Create(ctx, existing); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	got, created, err := c.FirstOrCreate(ctx, Q(map[string]any{"email": "a@example.com"}), &testAccount{Name: "ignored"})
+	if err != nil {
+		t.Fatalf("FirstOrCreate(existing) error: %v", err)
+	}
+	if created || got.ID != existing.ID || got.Name != "Alice" {
+		t.Errorf("FirstOrCreate(existing) = %+v, created=%v, want %+v, created=false", got, created, existing)
+	}
+
+	got, created, err = c.FirstOrCreate(ctx, Q(map[string]any{"email": "b@example.com"}), &testAccount{Name: "Bob"})
+	if err != nil {
+		t.Fatalf("FirstOrCreate(new) error: %v", err)
+	}
+	if !created || got.ID == 0 || got.Email != "b@example.com" || got.Name != "Bob" {
+		t.Errorf("FirstOrCreate(new) = %+v, created=%v, want a new Bob with email b@example.com", got, created)
+	}
+
+	if n, err := c.Count(ctx, Q(nil)); err != nil || n != 2 {
+		t.Errorf("Count() = %d, %v, want 2 accounts", n, err)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("primary key", func(t *testing.T) {
+		c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+		seedUsers(t, c, 2)
+
+		if err := c.Upsert(ctx, nil, &testUser{ID: 1, Name: "changed", Age: 42}, &testUser{ID: 3, Name: "new"}); err != nil {
+			t.Fatalf("Upsert() error: %v", err)
+		}
+
+		res, err := c.List(ctx, Q(nil), OrderBy("id"))
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		if len(res.Items) != 3 {
+			t.Fatalf("got %d rows, want 3", len(res.Items))
+		}
+		if u := res.Items[0]; u.Name != "changed" || u.Age != 42 {
+			t.Errorf("row 1 = %+v, want updated name and age", u)
+		}
+	})
+
+	t.Run("unique column", func(t *testing.T) {
+		c := NewCRUD[testAccount](newTestDB(t, &testAccount{}))
+		if err := c.Create(ctx, &testAccount{Email: "a@example.com", Name: "before"}); err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+
+		if err := c.Upsert(ctx, []string{"email"}, &testAccount{Email: "a@example.com", Name: "after"}); err != nil {
+			t.Fatalf("Upsert() error: %v", err)
+		}
+
+		res, err := c.List(ctx, Q(nil))
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		if len(res.Items) != 1 || res.Items[0].Name != "after" {
+			t.Errorf("got %+v, want a single updated account", res.Items)
+		}
+	})
+}
+
+func TestCountExists(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 5)
+	if _, err := c.Update(ctx, Q(map[string]any{"id": 5}), map[string]any{"status": "banned"}); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		query  *Query
+		count  int64
+		exists bool
+	}{
+		{name: "zero", query: Q(map[string]any{"status": "deleted"}), count: 0, exists: false},
+		{name: "one", query: Q(map[string]any{"status": "banned"}), count: 1, exists: true},
+		{name: "many", query: Q(map[string]any{"status": "active"}), count: 4, exists: true},
+		{name: "with not", query: Q(nil).Not(map[string]any{"status": "active"}), count: 1, exists: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := c.Count(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("Count() error: %v", err)
+			}
+			if count != tt.count {
+				t.Errorf("Count() = %d, want %d", count, tt.count)
+			}
+
+			exists, err := c.Exists(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("Exists() error: %v", err)
+			}
+			if exists != tt.exists {
+				t.Errorf("Exists() = %v, want %v", exists, tt.exists)
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 3)
+
+	got, err := c.Get(ctx, Q(map[string]any{"id": 2}), Select("id", "name"))
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if want := (testUser{ID: 2, Name: "user-2"}); *got != want {
+		t.Errorf("Get() = %+v, want %+v", *got, want)
+	}
+
+	res, err := c.List(ctx, Q(nil), Select("age"), OrderBy("age desc"), Pagination(1, 2))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if res.Total != 3 {
+		t.Errorf("List() Total = %d, want 3", res.Total)
+	}
+	for i, u := range res.Items {
+		if want := (testUser{Age: 3 - i}); *u != want {
+			t.Errorf("List() item %d = %+v, want %+v", i, *u, want)
+		}
+	}
+}
+
+func TestQuery_Or(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 5)
+
+	tests := []struct {
+		name  string
+		query *Query
+		want  []uint
+	}{
+		{name: "either clause", query: Q(map[string]any{"age": 1}).Or(map[string]any{"name": "user-3"}), want: []uint{1, 3}},
+		{name: "multiple groups", query: Q(map[string]any{"age": 1}).Or(map[string]any{"age": 2}).Or(map[string]any{"age": 5}), want: []uint{1, 2, 5}},
+		{name: "only or", query: Q(nil).Or(map[string]any{"age": 4}), want: []uint{4}},
+		{name: "not applies to all groups", query: Q(map[string]any{"age": 1}).Or(map[string]any{"age": 2}).Not(map[string]any{"name": "user-1"}), want: []uint{2}},
+		{name: "and within a group", query: Q(map[string]any{"age": 1, "name": "user-2"}).Or(map[string]any{"age": 3}), want: []uint{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.List(ctx, tt.query, OrderBy("id"))
+			if err != nil {
+				t.Fatalf("List() error: %v", err)
+			}
+
+			if got := userIDs(res.Items); !slices.Equal(got, tt.want) {
+				t.Errorf("List() ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuery_operators(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 12)
+
+	tests := []struct {
+		name  string
+		query *Query
+		want  []uint
+	}{
+		{name: "Gt", query: Q(nil).Gt("age", 10), want: []uint{11, 12}},
+		{name: "Gte", query: Q(nil).Gte("age", 11), want: []uint{11, 12}},
+		{name: "Lt", query: Q(nil).Lt("age", 3), want: []uint{1, 2}},
+		{name: "Lte", query: Q(nil).Lte("age", 2), want: []uint{1, 2}},
+		{name: "In", query: Q(nil).In("name", []string{"user-4", "user-7", "missing"}), want: []uint{4, 7}},
+		{name: "Like", query: Q(nil).Like("name", "user-1%"), want: []uint{1, 10, 11, 12}},
+		{name: "range", query: Q(nil).Gt("age", 3).Lte("age", 5), want: []uint{4, 5}},
+		{name: "with equality", query: Q(map[string]any{"name": "user-5"}).Gte("age", 5), want: []uint{5}},
+		{name: "with or", query: Q(nil).Lt("age", 2).Or(map[string]any{"age": 12}), want: []uint{1, 12}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.List(ctx, tt.query, OrderBy("id"))
+			if err != nil {
+				t.Fatalf("List() error: %v", err)
+			}
+
+			if got := userIDs(res.Items); !slices.Equal(got, tt.want) {
+				t.Errorf("List() ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type testDepartment struct {
+	Name      string
+	Employees []testEmployee `gorm:"foreignKey:DeptCode;references:Code"`
+	Code      string         `gorm:"primaryKey"`
+}
+
+type testEmployee struct {
+	Name     string
+	DeptCode string
+	ID       uint `gorm:"primaryKey"`
+}
+
+func TestGetByID(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testDepartment{}, &testEmployee{})
+	c := NewCRUD[testDepartment](db)
+
+	err := c.Create(ctx,
+		&testDepartment{Code: "ENG", Name: "Engineering", Employees: []testEmployee{{Name: "Alice"}, {Name: "Bob"}}},
+		&testDepartment{Code: "OPS", Name: "Operations"},
+	)
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	got, err := c.GetByID(ctx, "ENG", Preload("Employees"))
+	if err != nil {
+		t.Fatalf("GetByID() error: %v", err)
+	}
+	if got.Name != "Engineering" || len(got.Employees) != 2 {
+		t.Errorf("GetByID() = %+v, want Engineering with 2 employees", got)
+	}
+
+	if _, err := c.GetByID(ctx, "HR"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID() missing error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+type testNote struct {
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Text      string
+	ID        uint `gorm:"primaryKey"`
+}
+
+func TestUnscoped(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testNote](newTestDB(t, &testNote{}))
+	if err := c.Create(ctx, &testNote{Text: "keep"}, &testNote{Text: "soft"}, &testNote{Text: "hard"}); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	if _, err := c.Delete(ctx, Q(map[string]any{"text": "soft"})); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+
+	count := func(opts ...QueryOptFn) int64 {
+		t.Helper()
+		n, err := c.Count(ctx, Q(nil), opts...)
+		if err != nil {
+			t.Fatalf("Count() error: %v", err)
+		}
+		return n
+	}
+
+	// Scoped reads hide the soft-deleted row
+	if _, err := c.Get(ctx, Q(map[string]any{"text": "soft"})); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("scoped Get() error = %v, want not found", err)
+	}
+	if res, err := c.List(ctx, Q(nil)); err != nil || len(res.Items) != 2 {
+		t.Errorf("scoped List() = %v, %v; want 2 items", res, err)
+	}
+
+	// Unscoped reads include it
+	got, err := c.Get(ctx, Q(map[string]any{"text": "soft"}), Unscoped())
+	if err != nil || !got.DeletedAt.Valid {
+		t.Errorf("unscoped Get() = %+v, %v; want the soft-deleted note", got, err)
+	}
+	if res, err := c.List(ctx, Q(nil), Unscoped()); err != nil || len(res.Items) != 3 {
+		t.Errorf("unscoped List() = %v, %v; want 3 items", res, err)
+	}
+	if n := count(Unscoped()); n != 3 {
+		t.Errorf("unscoped Count() = %d, want 3", n)
+	}
+
+	// Unscoped delete removes the row permanently
+	if _, err := c.Delete(ctx, Q(map[string]any{"text": "hard"}), Unscoped()); err != nil {
+		t.Fatalf("unscoped Delete() error: %v", err)
+	}
+	if n := count(Unscoped()); n != 2 {
+		t.Errorf("unscoped Count() after hard delete = %d, want 2", n)
+	}
+	if n := count(); n != 1 {
+		t.Errorf("scoped Count() = %d, want 1", n)
+	}
+}
+
+// newPostgresDryRun returns a postgres DB that never connects and records the SQL of each query
+func newPostgresDryRun(t *testing.T) (*gorm.DB, *[]string) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open postgres dry run: %v", err)
+	}
+
+	var statements []string
+	err = db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+	if err != nil {
+		t.Fatalf("register capture callback: %v", err)
+	}
+
+	return db, &statements
+}
+
+func TestGet_locking(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		opt  QueryOptFn
+		want string
+	}{
+		{name: "for update", opt: LockForUpdate(), want: "FOR UPDATE"},
+		{name: "for share", opt: LockForShare(), want: "FOR SHARE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := newPostgresDryRun(t)
+			c := NewCRUD[testUser](db)
+
+			if _, err := c.Get(ctx, Q(map[string]any{"id": 1}), tt.opt); err != nil {
+				t.Fatalf("Get() error: %v", err)
+			}
+			if len(*statements) != 1 || !strings.HasSuffix((*statements)[0], tt.want) {
+				t.Errorf("Get() SQL = %q, want it to end with %q", *statements, tt.want)
+			}
+		})
+	}
+
+	t.Run("inside transaction", func(t *testing.T) {
+		c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+		seedUsers(t, c, 1)
+
+		err := c.Transaction(ctx, func(ctx context.Context) error {
+			u, err := c.Get(ctx, Q(map[string]any{"id": 1}), LockForUpdate())
+			if err != nil {
+				return err
+			}
+			_, err = c.Update(ctx, Q(map[string]any{"id": u.ID}), map[string]any{"age": u.Age + 1})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Transaction() error: %v", err)
+		}
+	})
+}
+
+type testDoc struct {
+	Title   string
+	ID      uint `gorm:"primaryKey"`
+	Version int
+	Rev     uint
+}
+
+func TestUpdateByFn_optimisticLock(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testDoc](newTestDB(t, &testDoc{}))
+	if err := c.Create(ctx, &testDoc{Title: "draft"}); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	byID := Q(map[string]any{"id": 1})
+
+	// A non-conflicting update bumps the version
+	err := c.UpdateByFn(ctx, byID, func(d *testDoc) (bool, error) {
+		d.Title = "first"
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateByFn() error: %v", err)
+	}
+	if got, _ := c.Get(ctx, byID); got.Title != "first" || got.Version != 1 {
+		t.Errorf("after update got %+v, want title first and version 1", got)
+	}
+
+	// Two writers load version 1; the second one to write must fail. The competing
+	// write is made on the same transaction between the read and the save.
+	err = c.Transaction(ctx, func(ctx context.Context) error {
+		return c.UpdateByFn(ctx, byID, func(d *testDoc) (bool, error) {
+			if _, err := c.Update(ctx, byID, map[string]any{"title": "winner", "version": d.Version + 1}); err != nil {
+				return false, err
+			}
+			d.Title = "loser"
+			return true, nil
+		})
+	})
+	if !errors.Is(err, ErrConcurrentUpdate) {
+		t.Fatalf("racing UpdateByFn() error = %v, want %v", err, ErrConcurrentUpdate)
+	}
+
+	// The racing update was rejected and nothing was inserted in its place
+	if n, _ := c.Count(ctx, Q(nil)); n != 1 {
+		t.Errorf("Count() = %d, want 1", n)
+	}
+
+	// A custom version column is honored
+	err = c.UpdateByFn(ctx, byID, func(d *testDoc) (bool, error) {
+		d.Title = "custom"
+		return true, nil
+	}, VersionColumn("rev"))
+	if err != nil {
+		t.Fatalf("UpdateByFn() with VersionColumn error: %v", err)
+	}
+	if got, _ := c.Get(ctx, byID); got.Title != "custom" || got.Rev != 1 || got.Version != 1 {
+		t.Errorf("after custom version update got %+v, want rev 1 and version untouched", got)
+	}
+}
+
+func TestDeleteByFn(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 2)
+
+	onlyMinors := func(u *testUser) (bool, error) { return u.Age < 2, nil }
+
+	// user-1 is deleted
+	if err := c.DeleteByFn(ctx, Q(map[string]any{"id": 1}), onlyMinors); err != nil {
+		t.Fatalf("DeleteByFn() error: %v", err)
+	}
+	if exists, _ := c.Exists(ctx, Q(map[string]any{"id": 1})); exists {
+		t.Error("user-1 should have been deleted")
+	}
+
+	// user-2 is skipped
+	if err := c.DeleteByFn(ctx, Q(map[string]any{"id": 2}), onlyMinors); err != nil {
+		t.Fatalf("DeleteByFn() error: %v", err)
+	}
+	if exists, _ := c.Exists(ctx, Q(map[string]any{"id": 2})); !exists {
+		t.Error("user-2 should have been kept")
+	}
+
+	// A predicate error aborts the delete
+	errVeto := errors.New("veto")
+	err := c.DeleteByFn(ctx, Q(map[string]any{"id": 2}), func(*testUser) (bool, error) { return true, errVeto })
+	if !errors.Is(err, errVeto) {
+		t.Errorf("DeleteByFn() error = %v, want %v", err, errVeto)
+	}
+
+	// Nothing matches
+	if err := c.DeleteByFn(ctx, Q(map[string]any{"id": 9}), onlyMinors); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteByFn() error = %v, want %v", err, ErrNotFound)
+	}
+	if err := c.DeleteByFn(ctx, Q(map[string]any{"id": 9}), onlyMinors, OmitNotFoundErr(func(error) error { return nil })); err != nil {
+		t.Errorf("DeleteByFn() with OmitNotFoundErr error = %v, want nil", err)
+	}
+}
+
+func TestCreateInBatches(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testUser{})
+	c := NewCRUD[testUser](db)
+
+	var statements int
+	err := db.Callback().Create().After("gorm:create").Register("test:count", func(*gorm.DB) { statements++ })
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	users := make([]*testUser, 1000)
+	for i := range users {
+		users[i] = &testUser{Name: "user-" + strconv.Itoa(i)}
+	}
+
+	if err := c.CreateInBatches(ctx, users, 100); err != nil {
+		t.Fatalf("CreateInBatches() error: %v", err)
+	}
+
+	if n, _ := c.Count(ctx, Q(nil)); n != 1000 {
+		t.Errorf("Count() = %d, want 1000", n)
+	}
+	if statements != 10 {
+		t.Errorf("issued %d INSERT statements, want 10", statements)
+	}
+}
+
+func TestCreateInBatches_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db := newTestDB(t, &testUser{})
+	c := NewCRUD[testUser](db)
+
+	var statements int
+	err := db.Callback().Create().After("gorm:create").Register("test:cancel", func(*gorm.DB) {
+		if statements++; statements == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	users := make([]*testUser, 100)
+	for i := range users {
+		users[i] = &testUser{Name: "user-" + strconv.Itoa(i)}
+	}
+
+	if err := c.CreateInBatches(ctx, users, 10); !errors.Is(err, context.Canceled) {
+		t.Fatalf("CreateInBatches() error = %v, want context.Canceled", err)
+	}
+	if statements != 2 {
+		t.Errorf("issued %d INSERT statements after cancelling, want 2", statements)
+	}
+	if n, _ := c.Count(context.Background(), Q(nil)); n >= int64(len(users)) {
+		t.Errorf("Count() = %d, want fewer than %d", n, len(users))
+	}
+}
+
+func TestListAll_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db := newTestDB(t, &testUser{})
+	c := NewCRUD[testUser](db)
+	seedUsers(t, c, 10)
+
+	var pages int
+	err := db.Callback().Query().After("gorm:query").Register("test:cancel", func(tx *gorm.DB) {
+		// Count queries scan into an int64, the pages into the entity slice
+		if _, ok := tx.Statement.Dest.(*[]*testUser); ok {
+			pages++
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	if _, err := c.ListAll(ctx, Q(nil), 3); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ListAll() error = %v, want context.Canceled", err)
+	}
+	if pages != 1 {
+		t.Errorf("loaded %d pages after cancelling, want 1", pages)
+	}
+}
+
+func TestCreateInBatches_failedBatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		create    func(ctx context.Context, c CRUD[testAccount], accounts []*testAccount) error
+		wantCount int64
+	}{
+		{
+			name: "atomic by default",
+			create: func(ctx context.Context, c CRUD[testAccount], accounts []*testAccount) error {
+				return c.CreateInBatches(ctx, accounts, 2)
+			},
+			wantCount: 1,
+		},
+		{
+			name: "continue on error",
+			create: func(ctx context.Context, c CRUD[testAccount], accounts []*testAccount) error {
+				return c.CreateInBatches(ctx, accounts, 2, ContinueOnError())
+			},
+			wantCount: 5,
+		},
+		{
+			// Each batch is a savepoint, so the failed one doesn't abort the transaction
+			name: "continue on error in a transaction",
+			create: func(ctx context.Context, c CRUD[testAccount], accounts []*testAccount) error {
+				var batchErr error
+				err := c.Transaction(ctx, func(ctx context.Context) error {
+					batchErr = c.CreateInBatches(ctx, accounts, 2, ContinueOnError())
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				return batchErr
+			},
+			wantCount: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewCRUD[testAccount](newTestDB(t, &testAccount{}))
+			if err := c.Create(ctx, &testAccount{Email: "taken@example.com"}); err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+
+			accounts := []*testAccount{
+				{Email: "a@example.com"}, {Email: "b@example.com"},
+				{Email: "c@example.com"}, {Email: "taken@example.com"},
+				{Email: "e@example.com"}, {Email: "f@example.com"},
+			}
+			err := tt.create(ctx, c, accounts)
+
+			var batchErr *BatchError
+			if !errors.As(err, &batchErr) {
+				t.Fatalf("CreateInBatches() error = %v, want a *BatchError", err)
+			}
+			if batchErr.Batches != 3 || len(batchErr.Failures) != 1 || batchErr.Failures[0].Index != 1 {
+				t.Errorf("BatchError = %+v, want batch 1 of 3 failed", batchErr)
+			}
+			if len(batchErr.Failures) > 0 && !errors.Is(err, batchErr.Failures[0].Err) {
+				t.Errorf("errors.Is() does not find the batch error in %v", err)
+			}
+			if !strings.Contains(err.Error(), "batch 1:") {
+				t.Errorf("error %q does not name the failed batch", err)
+			}
+
+			if n, _ := c.Count(ctx, Q(nil)); n != tt.wantCount {
+				t.Errorf("Count() = %d, want %d", n, tt.wantCount)
+			}
+		})
+	}
+}
+
+type testTag struct {
+	CreatedAt time.Time
+	Name      string
+	Color     string
+	ID        uint `gorm:"primaryKey"`
+	UserID    uint
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testTag](newTestDB(t, &testTag{}))
+	if err := c.Create(ctx,
+		&testTag{UserID: 1, Name: "go", Color: "blue"},
+		&testTag{UserID: 1, Name: "rust", Color: "orange"},
+		&testTag{UserID: 2, Name: "go", Color: "blue"},
+	); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	goTag, err := c.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetByID() error: %v", err)
+	}
+
+	nameKey := func(tag *testTag) any { return tag.Name }
+	desired := []*testTag{
+		{UserID: 1, Name: "go", Color: "green"},
+		{UserID: 1, Name: "zig", Color: "yellow"},
+	}
+	if err := c.Sync(ctx, Q(map[string]any{"user_id": 1}), desired, nameKey); err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+
+	res, err := c.List(ctx, Q(nil), OrderBy("id"))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	got := make([]string, len(res.Items))
+	for i, tag := range res.Items {
+		got[i] = strconv.Itoa(int(tag.ID)) + ":" + strconv.Itoa(int(tag.UserID)) + ":" + tag.Name + ":" + tag.Color
+	}
+	// go keeps its ID with the new color, rust is deleted, zig is created, user 2 is untouched
+	if want := []string{"1:1:go:green", "3:2:go:blue", "4:1:zig:yellow"}; !slices.Equal(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if !res.Items[0].CreatedAt.Equal(goTag.CreatedAt) || goTag.CreatedAt.IsZero() {
+		t.Errorf("synced go CreatedAt = %v, want the original %v", res.Items[0].CreatedAt, goTag.CreatedAt)
+	}
+	if !desired[0].CreatedAt.Equal(goTag.CreatedAt) {
+		t.Errorf("desired go CreatedAt = %v, want the original %v", desired[0].CreatedAt, goTag.CreatedAt)
+	}
+
+	dup := []*testTag{{UserID: 1, Name: "go"}, {UserID: 1, Name: "go"}}
+	if err := c.Sync(ctx, Q(map[string]any{"user_id": 1}), dup, nameKey); err == nil {
+		t.Error("Sync() with duplicate keys succeeded, want an error")
+	}
+}
+
+func TestSync_duplicateCurrentKeys(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testTag](newTestDB(t, &testTag{}))
+	if err := c.Create(ctx,
+		&testTag{UserID: 1, Name: "go", Color: "blue"},
+		&testTag{UserID: 1, Name: "go", Color: "red"},
+		&testTag{UserID: 1, Name: "rust", Color: "orange"},
+	); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	nameKey := func(tag *testTag) any { return tag.Name }
+	if err := c.Sync(ctx, Q(map[string]any{"user_id": 1}), []*testTag{{UserID: 1, Name: "go", Color: "green"}}, nameKey); err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+
+	res, err := c.List(ctx, Q(nil))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(res.Items) != 1 || res.Items[0].Name != "go" || res.Items[0].Color != "green" {
+		t.Errorf("rows = %+v, want the green go tag only", res.Items)
+	}
+}
+
+func TestNewCRUDWithOpts_defaultPageSize(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testUser{})
+	c := NewCRUDWithOpts[testUser](db, WithDefaultPageSize(3))
+
+	users := make([]*testUser, 7)
+	for i := range users {
+		users[i] = &testUser{Name: "user" + strconv.Itoa(i), Age: i}
+	}
+	if err := c.Create(ctx, users...); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	res, err := c.List(ctx, Q(nil))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(res.Items) != 3 || res.PageSize != 3 || res.PageCount != 3 || res.Total != 7 {
+		t.Errorf("List() = %d items, page size %d, %d pages, total %d; want 3, 3, 3, 7",
+			len(res.Items), res.PageSize, res.PageCount, res.Total)
+	}
+
+	// Per-call options still win over the defaults
+	if res, err = c.List(ctx, Q(nil), Pagination(1, 5)); err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(res.Items) != 5 {
+		t.Errorf("List(Pagination(1, 5)) = %d items, want 5", len(res.Items))
+	}
+
+	// NewCRUD keeps listing everything
+	if res, err = NewCRUD[testUser](db).List(ctx, Q(nil)); err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(res.Items) != 7 {
+		t.Errorf("NewCRUD List() = %d items, want 7", len(res.Items))
+	}
+}
+
+func TestNewCRUDWithOpts_defaultUnscoped(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testNote{})
+	if err := NewCRUD[testNote](db).Create(ctx, &testNote{Text: "kept"}, &testNote{Text: "gone"}); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if _, err := NewCRUD[testNote](db).Delete(ctx, Q(map[string]any{"text": "gone"})); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+
+	// The observer decorator must pass the defaults on to the package functions
+	c := NewCRUDWithOpts[testNote](db, WithDefaultUnscoped(),
+		WithCRUDObserver(ObserverFunc(func(context.Context, string, time.Duration, error) {})))
+	gone := Q(map[string]any{"id": 2})
+
+	if _, err := c.Get(ctx, gone); err != nil {
+		t.Errorf("Get() error: %v", err)
+	}
+	if found, err := c.Exists(ctx, gone); err != nil || !found {
+		t.Errorf("Exists() = %v, %v; want true", found, err)
+	}
+	if n, err := c.Count(ctx, Q(nil)); err != nil || n != 2 {
+		t.Errorf("Count() = %d, %v; want 2", n, err)
+	}
+	if n, err := c.Update(ctx, gone, map[string]any{"text": "update"}); err != nil || n != 1 {
+		t.Errorf("Update() = %d, %v; want 1", n, err)
+	}
+	if n, err := c.UpdateByIDs(ctx, []any{2}, map[string]any{"text": "update-by-ids"}); err != nil || n != 1 {
+		t.Errorf("UpdateByIDs() = %d, %v; want 1", n, err)
+	}
+	if got, err := c.UpdateReturning(ctx, gone, map[string]any{"text": "update-returning"}); err != nil || got.Text != "update-returning" {
+		t.Errorf("UpdateReturning() = %+v, %v; want text update-returning", got, err)
+	}
+	err := c.UpdateByFn(ctx, gone, func(n *testNote) (bool, error) {
+		n.Text = "update-by-fn"
+		return true, nil
+	})
+	if err != nil {
+		t.Errorf("UpdateByFn() error: %v", err)
+	}
+	if got, created, err := c.FirstOrCreate(ctx, Q(map[string]any{"text": "update-by-fn"}), nil); err != nil || created || got.ID != 2 {
+		t.Errorf("FirstOrCreate() = %+v, %v, %v; want the soft-deleted note", got, created, err)
+	}
+	if ids, err := PluckColumn[uint](ctx, c, Q(nil), "id", OrderBy("id")); err != nil || !slices.Equal(ids, []uint{1, 2}) {
+		t.Errorf("PluckColumn() = %v, %v; want [1 2]", ids, err)
+	}
+	if byID, err := GetByIDs(ctx, c, "id", []uint{1, 2}); err != nil || len(byID) != 2 {
+		t.Errorf("GetByIDs() = %v, %v; want 2 notes", byID, err)
+	}
+
+	// Sync sees the soft-deleted note and, unscoped, removes it for good
+	textKey := func(n *testNote) any { return n.Text }
+	if err := c.Sync(ctx, Q(nil), []*testNote{{Text: "kept"}}, textKey); err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+	if n, err := NewCRUD[testNote](db).Count(ctx, Q(nil), Unscoped()); err != nil || n != 1 {
+		t.Errorf("Count() after Sync = %d, %v; want 1", n, err)
+	}
+}
+
+func TestUpdateReturning(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 2)
+
+	got, err := c.UpdateReturning(ctx, Q(map[string]any{"name": "user-2"}), map[string]any{"name": "renamed", "age": 20})
+	if err != nil {
+		t.Fatalf("UpdateReturning() error: %v", err)
+	}
+	if want := (testUser{ID: 2, Name: "renamed", Age: 20, Status: "active"}); *got != want {
+		t.Errorf("UpdateReturning() = %+v, want %+v", *got, want)
+	}
+
+	stored, err := c.Get(ctx, Q(map[string]any{"id": 2}))
+	if err != nil || *stored != *got {
+		t.Errorf("stored row = %+v, %v; want %+v", stored, err, *got)
+	}
+
+	if _, err := c.UpdateReturning(ctx, Q(map[string]any{"name": "missing"}), map[string]any{"age": 1}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateReturning() missing error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+// TestUpdateReturning_reselect covers the dialects without RETURNING, like MySQL, by dropping the
+// clause sqlite supports so UpdateReturning reads the row back after the update
+func TestUpdateReturning_reselect(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testUser{})
+	c := NewCRUD[testUser](db)
+	seedUsers(t, c, 2)
+
+	update := db.Callback().Update()
+	update.Clauses = slices.DeleteFunc(slices.Clone(update.Clauses), func(name string) bool { return name == "RETURNING" })
+
+	var updates []string
+	if err := update.After("gorm:update").Register("test:sql", func(tx *gorm.DB) {
+		updates = append(updates, tx.Statement.SQL.String())
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	var queries int
+	if err := db.Callback().Query().After("gorm:query").Register("test:count", func(*gorm.DB) { queries++ }); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	got, err := c.UpdateReturning(ctx, Q(map[string]any{"name": "user-2"}), map[string]any{"name": "renamed", "age": 20})
+	if err != nil {
+		t.Fatalf("UpdateReturning() error: %v", err)
+	}
+	if want := (testUser{ID: 2, Name: "renamed", Age: 20, Status: "active"}); *got != want {
+		t.Errorf("UpdateReturning() = %+v, want %+v", *got, want)
+	}
+	if len(updates) != 1 || strings.Contains(updates[0], "RETURNING") {
+		t.Errorf("update statements = %q, want one without RETURNING", updates)
+	}
+	if queries != 2 {
+		t.Errorf("issued %d queries, want the lookup and the re-select", queries)
+	}
+
+	stored, err := c.Get(ctx, Q(map[string]any{"id": 2}))
+	if err != nil || *stored != *got {
+		t.Errorf("stored row = %+v, %v; want %+v", stored, err, *got)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testUser{})
+	c := NewCRUD[testUser](db)
+	seedUsers(t, c, 1)
+
+	// Make every query slower than the timeout
+	err := db.Callback().Query().Before("gorm:query").Register("test:slow", func(*gorm.DB) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	err = db.Callback().Row().Before("gorm:row").Register("test:slow", func(*gorm.DB) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	timeout := Timeout(10 * time.Millisecond)
+
+	if _, err := c.Get(ctx, Q(map[string]any{"id": 1}), timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := c.List(ctx, Q(nil), timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("List() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := c.Count(ctx, Q(nil), timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Count() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// A generous timeout doesn't get in the way
+	if _, err := c.Get(ctx, Q(map[string]any{"id": 1}), Timeout(time.Second)); err != nil {
+		t.Errorf("Get() with a large timeout error = %v", err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testUser{})
+	c := NewCRUD[testUser](db)
+	users := make([]*testUser, 300)
+	for i := range users {
+		users[i] = &testUser{Name: "user-" + strconv.Itoa(i+1), Age: i % 7}
+	}
+	if err := c.CreateInBatches(ctx, users, 100); err != nil {
+		t.Fatalf("CreateInBatches() error: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("db.DB() error: %v", err)
+	}
+
+	t.Run("full iteration", func(t *testing.T) {
+		seq, err := c.Stream(ctx, Q(nil).Gt("age", 0), OrderBy("id desc"))
+		if err != nil {
+			t.Fatalf("Stream() error: %v", err)
+		}
+
+		var count int
+		lastID := uint(len(users) + 1)
+		for u, err := range seq {
+			if err != nil {
+				t.Fatalf("iteration error: %v", err)
+			}
+			if u.Age == 0 || u.ID >= lastID {
+				t.Fatalf("got %+v after id %d, want age > 0 in descending id order", u, lastID)
+			}
+			lastID = u.ID
+			count++
+		}
+
+		if want, _ := c.Count(ctx, Q(nil).Gt("age", 0)); int64(count) != want {
+			t.Errorf("streamed %d rows, want %d", count, want)
+		}
+	})
+
+	t.Run("early break", func(t *testing.T) {
+		seq, err := c.Stream(ctx, Q(nil))
+		if err != nil {
+			t.Fatalf("Stream() error: %v", err)
+		}
+
+		var count int
+		for _, err := range seq {
+			if err != nil {
+				t.Fatalf("iteration error: %v", err)
+			}
+			if count++; count == 10 {
+				break
+			}
+		}
+
+		if inUse := sqlDB.Stats().InUse; inUse != 0 {
+			t.Errorf("%d connections still in use after break, want rows closed", inUse)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		seq, err := c.Stream(ctx, Q(nil))
+		if err != nil {
+			t.Fatalf("Stream() error: %v", err)
+		}
+
+		var gotErr error
+		for _, err := range seq {
+			if err != nil {
+				gotErr = err
+				break
+			}
+			cancel()
+		}
+
+		if !errors.Is(gotErr, context.Canceled) {
+			t.Errorf("iteration error = %v, want %v", gotErr, context.Canceled)
+		}
+		if inUse := sqlDB.Stats().InUse; inUse != 0 {
+			t.Errorf("%d connections still in use after cancel, want rows closed", inUse)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		seq, err := c.Stream(ctx, Q(nil), Timeout(20*time.Millisecond))
+		if err != nil {
+			t.Fatalf("Stream() error: %v", err)
+		}
+
+		var gotErr error
+		for _, err := range seq {
+			if err != nil {
+				gotErr = err
+				break
+			}
+			time.Sleep(30 * time.Millisecond)
+		}
+
+		if !errors.Is(gotErr, context.DeadlineExceeded) {
+			t.Errorf("iteration error = %v, want %v", gotErr, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("distinct", func(t *testing.T) {
+		seq, err := c.Stream(ctx, Q(nil), Select("age"), Distinct(), OrderBy("age"))
+		if err != nil {
+			t.Fatalf("Stream() error: %v", err)
+		}
+
+		var ages []int
+		for u, err := range seq {
+			if err != nil {
+				t.Fatalf("iteration error: %v", err)
+			}
+			ages = append(ages, u.Age)
+		}
+		if want := []int{0, 1, 2, 3, 4, 5, 6}; !slices.Equal(ages, want) {
+			t.Errorf("streamed ages = %v, want %v", ages, want)
+		}
+	})
+
+	t.Run("preload", func(t *testing.T) {
+		if _, err := c.Stream(ctx, Q(nil), Preload("Orders")); err == nil {
+			t.Error("Stream() with Preload succeeded, want an error")
+		}
+	})
+}
+
+type testOrder struct {
+	Status     string
+	ID         uint `gorm:"primaryKey"`
+	TestUserID uint
+}
+
+func TestJoins(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testUser{}, &testOrder{})
+	c := NewCRUD[testUser](db)
+	seedUsers(t, c, 3)
+
+	orders := NewCRUD[testOrder](db)
+	err := orders.Create(ctx,
+		&testOrder{TestUserID: 1, Status: "paid"},
+		&testOrder{TestUserID: 2, Status: "pending"},
+		&testOrder{TestUserID: 3, Status: "paid"},
+	)
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	joinOrders := Joins("LEFT JOIN test_orders ON test_orders.test_user_id = test_users.id")
+	paid := Q(map[string]any{"test_orders.status": "paid"})
+
+	res, err := c.List(ctx, paid, joinOrders, OrderBy("test_users.id"), Pagination(1, 10))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if got := userIDs(res.Items); !slices.Equal(got, []uint{1, 3}) || res.Total != 2 {
+		t.Errorf("List() ids = %v total = %d, want [1 3] total 2", got, res.Total)
+	}
+	if res.Items[0].Name != "user-1" {
+		t.Errorf("List() item = %+v, want the user columns populated", res.Items[0])
+	}
+
+	got, err := c.Get(ctx, paid.Gt("test_users.age", 1), joinOrders, Select("test_users.id", "test_users.name"))
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if want := (testUser{ID: 3, Name: "user-3"}); *got != want {
+		t.Errorf("Get() = %+v, want %+v", *got, want)
+	}
+
+	seq, err := c.Stream(ctx, Q(map[string]any{"test_orders.status": "paid"}), joinOrders, OrderBy("test_users.id"))
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+	var streamed []*testUser
+	for u, err := range seq {
+		if err != nil {
+			t.Fatalf("Stream() iteration error: %v", err)
+		}
+		streamed = append(streamed, u)
+	}
+	if got := userIDs(streamed); !slices.Equal(got, []uint{1, 3}) {
+		t.Errorf("Stream() ids = %v, want [1 3]", got)
+	}
+}
+
+func TestUpdateByIDs(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	users := seedUsers(t, c, 1200)
+
+	// More IDs than fit one chunk, plus one that doesn't exist
+	var ids []any
+	for _, u := range users[:1100] {
+		if u.ID%2 == 0 {
+			ids = append(ids, u.ID)
+		}
+	}
+	ids = append(ids, 99999)
+
+	n, err := c.UpdateByIDs(ctx, ids, map[string]any{"status": "banned"})
+	if err != nil {
+		t.Fatalf("UpdateByIDs() error: %v", err)
+	}
+	if n != 550 {
+		t.Errorf("UpdateByIDs() affected %d rows, want 550", n)
+	}
+
+	banned, err := c.Count(ctx, Q(map[string]any{"status": "banned"}))
+	if err != nil {
+		t.Fatalf("Count() error: %v", err)
+	}
+	if banned != 550 {
+		t.Errorf("%d banned users, want 550", banned)
+	}
+	if got, err := c.GetByID(ctx, users[1].ID); err != nil || got.Status != "banned" {
+		t.Errorf("GetByID(%d) = %+v, %v, want status banned", users[1].ID, got, err)
+	}
+	if got, err := c.GetByID(ctx, users[1101].ID); err != nil || got.Status != "active" {
+		t.Errorf("GetByID(%d) = %+v, %v, want status active", users[1101].ID, got, err)
+	}
+
+	if n, err := c.UpdateByIDs(ctx, nil, map[string]any{"status": "banned"}); err != nil || n != 0 {
+		t.Errorf("UpdateByIDs(nil) = %d, %v, want 0, nil", n, err)
+	}
+}
+
+func TestUpdateDelete_affected(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 5)
+
+	tests := []struct {
+		name  string
+		query *Query
+		want  int64
+	}{
+		{name: "several", query: Q(nil).Lte("age", 3), want: 3},
+		{name: "one", query: Q(map[string]any{"name": "user-4"}), want: 1},
+		{name: "none", query: Q(map[string]any{"name": "nobody"}), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := c.Update(ctx, tt.query, map[string]any{"status": "banned"})
+			if err != nil {
+				t.Fatalf("Update() error: %v", err)
+			}
+			if updated != tt.want {
+				t.Errorf("Update() affected %d rows, want %d", updated, tt.want)
+			}
+
+			deleted, err := c.Delete(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("Delete() error: %v", err)
+			}
+			if deleted != tt.want {
+				t.Errorf("Delete() affected %d rows, want %d", deleted, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelete_global(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testUser{}, &testNote{})
+	users := NewCRUD[testUser](db)
+	seedUsers(t, users, 3)
+
+	if _, err := users.Delete(ctx, Q(nil)); !errors.Is(err, ErrGlobalDelete) {
+		t.Errorf("Delete() without conditions error = %v, want %v", err, ErrGlobalDelete)
+	}
+	if n, _ := users.Count(ctx, Q(nil)); n != 3 {
+		t.Fatalf("blocked Delete() left %d users, want 3", n)
+	}
+
+	n, err := users.Delete(ctx, Q(nil), AllowGlobalDelete())
+	if err != nil {
+		t.Fatalf("Delete(AllowGlobalDelete) error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Delete(AllowGlobalDelete) affected %d rows, want 3", n)
+	}
+
+	// Soft deletes are updates, which gorm guards separately
+	notes := NewCRUD[testNote](db)
+	if err := notes.Create(ctx, &testNote{Text: "a"}, &testNote{Text: "b"}); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if n, err := notes.Delete(ctx, Q(nil), AllowGlobalDelete()); err != nil || n != 2 {
+		t.Errorf("soft Delete(AllowGlobalDelete) = %d, %v, want 2, nil", n, err)
+	}
+}
+
+func TestScope(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 6)
+	if _, err := c.Update(ctx, Q(nil).In("id", []int{2, 5}), map[string]any{"status": "banned"}); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	active := func(db *gorm.DB) *gorm.DB { return db.Where("status = ?", "active") }
+	olderThan := func(age int) func(*gorm.DB) *gorm.DB {
+		return func(db *gorm.DB) *gorm.DB { return db.Where("age > ?", age) }
+	}
+	scopes := []QueryOptFn{Scope(active), Scope(olderThan(2))}
+
+	res, err := c.List(ctx, Q(nil), append(scopes, OrderBy("id"))...)
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if got, want := userIDs(res.Items), []uint{3, 4, 6}; !slices.Equal(got, want) {
+		t.Errorf("List() ids = %v, want %v", got, want)
+	}
+
+	n, err := c.Count(ctx, Q(nil).Lt("age", 6), scopes...)
+	if err != nil || n != 2 {
+		t.Errorf("Count() = %d, %v, want 2, nil", n, err)
+	}
+
+	got, err := c.Get(ctx, Q(nil).Gt("age", 4), scopes...)
+	if err != nil || got.ID != 6 {
+		t.Errorf("Get() = %+v, %v, want user 6", got, err)
+	}
+
+	deleted, err := c.Delete(ctx, Q(nil), scopes...)
+	if err != nil || deleted != 3 {
+		t.Errorf("Delete() = %d, %v, want 3, nil", deleted, err)
+	}
+}
+
+type testEvent struct {
+	CreatedAt time.Time
+	Kind      string
+	ID        uint `gorm:"primaryKey"`
+}
+
+func TestWhere(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testEvent](newTestDB(t, &testEvent{}))
+
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	if err := c.Create(ctx,
+		&testEvent{Kind: "login", CreatedAt: since.Add(-time.Hour)},
+		&testEvent{Kind: "login", CreatedAt: since.Add(time.Hour)},
+		&testEvent{Kind: "logout", CreatedAt: since.Add(2 * time.Hour)},
+		&testEvent{Kind: "login", CreatedAt: since.Add(3 * time.Hour)},
+	); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	logins := Q(map[string]any{"kind": "login"})
+	res, err := c.List(ctx, logins, Where("created_at > ?", since), OrderBy("id"))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	var ids []uint
+	for _, e := range res.Items {
+		ids = append(ids, e.ID)
+	}
+	if want := []uint{2, 4}; !slices.Equal(ids, want) {
+		t.Errorf("List() ids = %v, want %v", ids, want)
+	}
+
+	before := func(db *gorm.DB) *gorm.DB { return db.Where("created_at < ?", since.Add(2*time.Hour)) }
+	n, err := c.Count(ctx, logins, Where("created_at > ?", since), WhereFn(before))
+	if err != nil || n != 1 {
+		t.Errorf("Count() = %d, %v, want 1, nil", n, err)
+	}
+
+	// A raw condition is enough for Delete to not be global
+	deleted, err := c.Delete(ctx, Q(nil), Where("created_at < ?", since))
+	if err != nil || deleted != 1 {
+		t.Errorf("Delete() = %d, %v, want 1, nil", deleted, err)
+	}
+}
+
+func TestFirstLast(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	users := seedUsers(t, c, 6)
+	// Age order differs from ID order: user 6 is the youngest
+	if _, err := c.Update(ctx, Q(map[string]any{"id": users[5].ID}), map[string]any{"age": 0}); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		fetch func(ctx context.Context, query *Query, n int, orderBy string) ([]*testUser, error)
+		query *Query
+		n     int
+		want  []uint
+	}{
+		{name: "first", fetch: c.First, query: Q(nil), n: 3, want: []uint{6, 1, 2}},
+		{name: "last", fetch: c.Last, query: Q(nil), n: 2, want: []uint{4, 5}},
+		{name: "filtered last", fetch: c.Last, query: Q(nil).Lt("age", 4), n: 2, want: []uint{2, 3}},
+		{name: "more than matching", fetch: c.First, query: Q(nil).Gt("age", 3), n: 10, want: []uint{4, 5}},
+		{name: "zero", fetch: c.Last, query: Q(nil), n: 0, want: []uint{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fetch(ctx, tt.query, tt.n, "age")
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if ids := userIDs(got); !slices.Equal(ids, tt.want) {
+				t.Errorf("ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+type testCustomer struct {
+	Name   string
+	Orders []testOrder `gorm:"foreignKey:TestUserID"`
+	ID     uint        `gorm:"primaryKey"`
+}
+
+func (testCustomer) TableName() string { return "test_users" }
+
+func TestPreloadWith(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testUser{}, &testOrder{})
+	seedUsers(t, NewCRUD[testUser](db), 2)
+
+	err := NewCRUD[testOrder](db).Create(ctx,
+		&testOrder{TestUserID: 1, Status: "paid"},
+		&testOrder{TestUserID: 1, Status: "pending"},
+		&testOrder{TestUserID: 1, Status: "paid"},
+		&testOrder{TestUserID: 2, Status: "pending"},
+	)
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	customers := NewCRUD[testCustomer](db)
+	paidOrders := PreloadWith("Orders", "status = ?", "paid")
+
+	got, err := customers.Get(ctx, Q(map[string]any{"id": 1}), paidOrders)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if len(got.Orders) != 2 {
+		t.Fatalf("Get() preloaded %d orders, want the 2 paid ones", len(got.Orders))
+	}
+	for _, order := range got.Orders {
+		if order.Status != "paid" {
+			t.Errorf("preloaded order %+v, want only paid orders", order)
+		}
+	}
+
+	res, err := customers.List(ctx, Q(nil), paidOrders, OrderBy("id"))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(res.Items) != 2 || len(res.Items[0].Orders) != 2 || len(res.Items[1].Orders) != 0 {
+		t.Errorf("List() = %+v, want both customers with only the paid orders of the first", res.Items)
+	}
+}
+
+type testStatusCount struct {
+	Status string
+	Total  int
+	MaxAge int
+}
+
+func (testStatusCount) TableName() string { return "test_users" }
+
+func TestGroupHaving(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testUser{})
+	users := NewCRUD[testUser](db)
+	seedUsers(t, users, 6)
+	if _, err := users.Update(ctx, Q(nil).In("id", []int{2, 4}), map[string]any{"status": "banned"}); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if _, err := users.Update(ctx, Q(map[string]any{"id": 6}), map[string]any{"status": "deleted"}); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	c := NewCRUD[testStatusCount](db)
+	aggregate := Select("status", "count(*) AS total", "max(age) AS max_age")
+
+	res, err := c.List(ctx, Q(nil), aggregate, Group("status"), OrderBy("status"))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	want := []testStatusCount{
+		{Status: "active", Total: 3, MaxAge: 5},
+		{Status: "banned", Total: 2, MaxAge: 4},
+		{Status: "deleted", Total: 1, MaxAge: 6},
+	}
+	if len(res.Items) != len(want) {
+		t.Fatalf("List() returned %d groups, want %d", len(res.Items), len(want))
+	}
+	for i, got := range res.Items {
+		if *got != want[i] {
+			t.Errorf("group %d = %+v, want %+v", i, *got, want[i])
+		}
+	}
+
+	res, err = c.List(ctx, Q(nil), aggregate, Group("status"), Having("count(*) > ?", 1), OrderBy("status"))
+	if err != nil {
+		t.Fatalf("List() with Having error: %v", err)
+	}
+	if len(res.Items) != 2 || res.Items[0].Status != "active" || res.Items[1].Status != "banned" {
+		t.Errorf("List() with Having = %+v, want the active and banned groups", res.Items)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+
+	sql, vars, err := c.ExplainList(ctx, Q(map[string]any{"status": "active"}).Gt("age", 18),
+		OrderBy("age DESC"), Pagination(2, 10))
+	if err != nil {
+		t.Fatalf("ExplainList() error: %v", err)
+	}
+	for _, fragment := range []string{"WHERE", "`status` = ?", "`age` > ?", "ORDER BY age DESC", "LIMIT 10", "OFFSET 10"} {
+		if !strings.Contains(sql, fragment) {
+			t.Errorf("ExplainList() SQL %q does not contain %q", sql, fragment)
+		}
+	}
+	if !slices.Equal(vars, []any{"active", 18}) {
+		t.Errorf("ExplainList() vars = %v, want [active 18]", vars)
+	}
+
+	// Nothing matches, but a dry run doesn't query so it can't report not found
+	sql, vars, err = c.ExplainGet(ctx, Q(map[string]any{"name": "nobody"}), LockForUpdate())
+	if err != nil {
+		t.Fatalf("ExplainGet() error: %v", err)
+	}
+	for _, fragment := range []string{"WHERE `test_users`.`name` = ?", "ORDER BY `test_users`.`id`", "LIMIT 1"} {
+		if !strings.Contains(sql, fragment) {
+			t.Errorf("ExplainGet() SQL %q does not contain %q", sql, fragment)
+		}
+	}
+	if !slices.Equal(vars, []any{"nobody"}) {
+		t.Errorf("ExplainGet() vars = %v, want [nobody]", vars)
+	}
+
+	if _, _, err := c.ExplainList(ctx, Q(nil), Cursor("id", nil, 10), Pagination(1, 10)); err == nil {
+		t.Error("ExplainList() should report invalid options")
+	}
+}
+
+func TestList_distinct(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	users := []*testUser{
+		{Name: "a", Status: "active", Age: 30},
+		{Name: "b", Status: "active", Age: 30},
+		{Name: "c", Status: "active", Age: 40},
+		{Name: "d", Status: "banned", Age: 30},
+	}
+	if err := c.Create(ctx, users...); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	pairs := func(res *ListRes[testUser]) []string {
+		var got []string
+		for _, u := range res.Items {
+			got = append(got, u.Status+"/"+strconv.Itoa(u.Age))
+		}
+		return got
+	}
+
+	res, err := c.List(ctx, Q(nil), Distinct("status", "age"), OrderBy("status", "age"))
+	if err != nil {
+		t.Fatalf("List(Distinct columns) error: %v", err)
+	}
+	if got, want := pairs(res), []string{"active/30", "active/40", "banned/30"}; !slices.Equal(got, want) {
+		t.Errorf("List(Distinct columns) = %v, want %v", got, want)
+	}
+
+	res, err = c.List(ctx, Q(map[string]any{"status": "active"}), Select("status", "age"), Distinct(), OrderBy("age"))
+	if err != nil {
+		t.Fatalf("List(Distinct projection) error: %v", err)
+	}
+	if got, want := pairs(res), []string{"active/30", "active/40"}; !slices.Equal(got, want) {
+		t.Errorf("List(Distinct projection) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package gormdb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrConcurrentUpdate is returned by UpdateByFn when the version column of the record
+// changed between the read and the write, meaning another writer updated it first.
+var ErrConcurrentUpdate = errors.New("gormdb: record was modified concurrently")
+
+// ErrGlobalDelete is returned by Delete for a query without conditions, which would delete
+// every record, unless the AllowGlobalDelete option is given.
+var ErrGlobalDelete = errors.New("gormdb: refusing to delete without conditions, pass AllowGlobalDelete to delete all records")
+
+// ErrNotFound is matched by the errors of CRUD calls that found no record, so callers can check
+// errors.Is(err, gormdb.ErrNotFound) without importing gorm. gorm.ErrRecordNotFound still matches too.
+var ErrNotFound = errors.New("gormdb: record not found")
+
+// notFoundError makes a gorm.ErrRecordNotFound chain also match ErrNotFound
+type notFoundError struct {
+	err error
+}
+
+func (e notFoundError) Error() string { return e.err.Error() }
+
+func (e notFoundError) Unwrap() error { return e.err }
+
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// translateNotFound wraps gorm.ErrRecordNotFound so that it matches ErrNotFound
+func translateNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, ErrNotFound) {
+		return notFoundError{err: err}
+	}
+	return err
+}
+
+// BatchFailure is the error of one batch of a batch operation, Index counting batches from 0.
+type BatchFailure struct {
+	Err   error
+	Index int
+}
+
+// BatchError is returned by batch operations when some of their Batches failed.
+// errors.Is and errors.As look through the errors of all failed batches.
+type BatchError struct {
+	Failures []BatchFailure
+	Batches  int
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		msgs[i] = fmt.Sprintf("batch %d: %v", f.Index, f.Err)
+	}
+	return fmt.Sprintf("gormdb: %d of %d batches failed: %s", len(e.Failures), e.Batches, strings.Join(msgs, "; "))
+}
+
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
@@ -0,0 +1,185 @@
+package gormdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Go methods can't have type parameters, so operations generic over a second type
+// are package functions taking the CRUD they run against.
+
+// ErrUnsupportedCRUD is returned by the package functions when given a CRUD implementation
+// that doesn't come from this package.
+var ErrUnsupportedCRUD = errors.New("gormdb: CRUD implementation does not expose its connection")
+
+// connProvider is implemented by the CRUD implementations of this package
+type connProvider interface {
+	conn(ctx context.Context) *gorm.DB
+	buildOpt(opts ...QueryOptFn) *QueryOpt
+}
+
+func connOf[T any](ctx context.Context, c CRUD[T]) (*gorm.DB, error) {
+	p, ok := c.(connProvider)
+	if !ok {
+		return nil, ErrUnsupportedCRUD
+	}
+
+	// Decorators return nil when the CRUD they wrap doesn't expose its connection
+	db := p.conn(ctx)
+	if db == nil {
+		return nil, ErrUnsupportedCRUD
+	}
+	return db, nil
+}
+
+// scopedConnOf is connOf with the defaults of c and opts applied, see WithDefaults.
+// The returned db can be reused for several statements.
+func scopedConnOf[T any](ctx context.Context, c CRUD[T], opts ...QueryOptFn) (*gorm.DB, *QueryOpt, error) {
+	db, err := connOf(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	o := c.(connProvider).buildOpt(opts...)
+	return o.scope(db).Session(&gorm.Session{}), o, nil
+}
+
+// PluckColumn returns the values of one column for the records matching the conditions,
+// without loading the full entities. OrderBy and Distinct are honored.
+//
+// Example:
+//
+//	ids, err := PluckColumn[int](ctx, users, Q(map[string]any{"status": "active"}), "id")
+func PluckColumn[V, T any](ctx context.Context, c CRUD[T], query *Query, column string, opts ...QueryOptFn) ([]V, error) {
+	db, o, err := scopedConnOf(ctx, c, opts...)
+	if err != nil {
+		return nil, err
+	}
+	db = query.apply(db.Model(new(T)))
+
+	for _, orderBy := range o.OrderBy {
+		db = db.Order(orderBy)
+	}
+
+	if o.Distinct {
+		db = db.Distinct()
+	}
+
+	values := make([]V, 0)
+	if err := db.Pluck(column, &values).Error; err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Raw runs a raw SQL statement, e.g. with CTEs or window functions the Query API can't express,
+// and scans the rows into R. It joins the transaction of ctx, but the statement isn't filtered
+// by NewTenantCRUD or the defaults of c, so it must restrict the tenant and soft-deleted records itself.
+//
+// Example:
+//
+//	stats, err := Raw[StatusCount](ctx, users, "SELECT status, count(*) AS total FROM users GROUP BY status")
+func Raw[R, T any](ctx context.Context, c CRUD[T], sql string, args ...any) ([]R, error) {
+	db, err := connOf(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]R, 0)
+	if err := db.Raw(sql, args...).Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// RawOne is like Raw but scans the first row only, returning ErrNotFound when there is none.
+func RawOne[R, T any](ctx context.Context, c CRUD[T], sql string, args ...any) (*R, error) {
+	db, err := connOf(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(R)
+	res := db.Raw(sql, args...).Scan(result)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, translateNotFound(gorm.ErrRecordNotFound)
+	}
+
+	return result, nil
+}
+
+// GetByIDs loads the records whose column value is in ids and returns them keyed by that value,
+// so missing ids are simply absent from the map. Long id lists are split over several queries.
+//
+// Example:
+//
+//	userByID, err := GetByIDs(ctx, users, "id", []uint{1, 2, 3})
+func GetByIDs[K comparable, T any](ctx context.Context, c CRUD[T], column string, ids []K) (map[K]*T, error) {
+	db, _, err := scopedConnOf(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	field := stmt.Schema.LookUpField(column)
+	if field == nil {
+		return nil, fmt.Errorf("gormdb: column %q not found in %s", column, stmt.Schema.Name)
+	}
+	keyType := reflect.TypeFor[K]()
+	if !keyAssignable(field.FieldType, keyType) {
+		return nil, fmt.Errorf("gormdb: column %q of type %s can't be a %s key", column, field.FieldType, keyType)
+	}
+
+	result := make(map[K]*T, len(ids))
+	for chunk := range slices.Chunk(ids, idChunkSize) {
+		var entities []*T
+		cond := clause.IN{Column: clause.Column{Name: field.DBName}, Values: toAnySlice(chunk)}
+		if err := db.Where(cond).Find(&entities).Error; err != nil {
+			return nil, err
+		}
+
+		for _, entity := range entities {
+			value, _ := field.ValueOf(ctx, reflect.ValueOf(entity))
+			result[reflect.ValueOf(value).Convert(keyType).Interface().(K)] = entity
+		}
+	}
+
+	return result, nil
+}
+
+// keyAssignable reports whether GetByIDs can key the values of a from column by type k.
+// Types of the same kind, like a named string and string, and numbers convert between each other,
+// but numbers don't convert to strings, where Convert yields the rune of the number.
+func keyAssignable(from, k reflect.Type) bool {
+	if from.AssignableTo(k) {
+		return true
+	}
+	if !from.ConvertibleTo(k) {
+		return false
+	}
+	return from.Kind() == k.Kind() || isNumber(from) && isNumber(k)
+}
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
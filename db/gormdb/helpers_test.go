@@ -0,0 +1,191 @@
+package gormdb
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestPluckColumn(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 4)
+	if _, err := c.Update(ctx, Q(map[string]any{"id": 2}), map[string]any{"status": "banned"}); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	ages, err := PluckColumn[int](ctx, c, Q(map[string]any{"status": "active"}), "age", OrderBy("age desc"))
+	if err != nil {
+		t.Fatalf("PluckColumn() error: %v", err)
+	}
+	if want := []int{4, 3, 1}; !slices.Equal(ages, want) {
+		t.Errorf("PluckColumn(age) = %v, want %v", ages, want)
+	}
+
+	names, err := PluckColumn[string](ctx, c, Q(nil).Not(map[string]any{"status": "active"}), "name")
+	if err != nil {
+		t.Fatalf("PluckColumn() error: %v", err)
+	}
+	if want := []string{"user-2"}; !slices.Equal(names, want) {
+		t.Errorf("PluckColumn(name) = %v, want %v", names, want)
+	}
+
+	none, err := PluckColumn[string](ctx, c, Q(map[string]any{"status": "deleted"}), "name")
+	if err != nil || none == nil || len(none) != 0 {
+		t.Errorf("PluckColumn() with no match = %#v, %v; want empty slice", none, err)
+	}
+}
+
+func TestPluckColumn_distinct(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 4)
+	if _, err := c.Update(ctx, Q(nil).In("id", []int{3, 4}), map[string]any{"status": "banned"}); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	statuses, err := PluckColumn[string](ctx, c, Q(nil), "status", Distinct(), OrderBy("status"))
+	if err != nil {
+		t.Fatalf("PluckColumn() error: %v", err)
+	}
+	if want := []string{"active", "banned"}; !slices.Equal(statuses, want) {
+		t.Errorf("PluckColumn(distinct status) = %v, want %v", statuses, want)
+	}
+}
+
+type testAgeStats struct {
+	Status string
+	Users  int
+	AvgAge float64
+}
+
+func TestRaw(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 5)
+	if _, err := c.Update(ctx, Q(nil).In("id", []int{4, 5}), map[string]any{"status": "banned"}); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	const stats = `WITH adults AS (SELECT * FROM test_users WHERE age >= ?)
+		SELECT status, count(*) AS users, avg(age) AS avg_age FROM adults GROUP BY status ORDER BY status`
+
+	got, err := Raw[testAgeStats](ctx, c, stats, 2)
+	if err != nil {
+		t.Fatalf("Raw() error: %v", err)
+	}
+	want := []testAgeStats{{Status: "active", Users: 2, AvgAge: 2.5}, {Status: "banned", Users: 2, AvgAge: 4.5}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Raw() = %+v, want %+v", got, want)
+	}
+
+	one, err := RawOne[testAgeStats](ctx, c, stats, 5)
+	if err != nil {
+		t.Fatalf("RawOne() error: %v", err)
+	}
+	if want := (testAgeStats{Status: "banned", Users: 1, AvgAge: 5}); *one != want {
+		t.Errorf("RawOne() = %+v, want %+v", *one, want)
+	}
+
+	if _, err := RawOne[testAgeStats](ctx, c, stats, 100); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RawOne() with no rows error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetByIDs(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+	seedUsers(t, c, 4)
+
+	got, err := GetByIDs(ctx, c, "id", []int{1, 3, 42})
+	if err != nil {
+		t.Fatalf("GetByIDs() error: %v", err)
+	}
+	if len(got) != 2 || got[1] == nil || got[1].Name != "user-1" || got[3] == nil || got[3].Name != "user-3" {
+		t.Errorf("GetByIDs() = %v, want users 1 and 3", got)
+	}
+	if _, ok := got[42]; ok {
+		t.Error("GetByIDs() has an entry for the missing id 42")
+	}
+
+	byName, err := GetByIDs(ctx, c, "name", []string{"user-2", "nobody"})
+	if err != nil {
+		t.Fatalf("GetByIDs(name) error: %v", err)
+	}
+	if len(byName) != 1 || byName["user-2"] == nil || byName["user-2"].ID != 2 {
+		t.Errorf("GetByIDs(name) = %v, want user 2 only", byName)
+	}
+
+	if _, err := GetByIDs(ctx, c, "nope", []int{1}); err == nil {
+		t.Error("GetByIDs() with an unknown column succeeded, want an error")
+	}
+
+	// Converting the uint ids to string would key them by "\x01", "\x02", ...
+	if _, err := GetByIDs(ctx, c, "id", []string{"1"}); err == nil {
+		t.Error("GetByIDs() with string keys for the uint id succeeded, want an error")
+	}
+	if _, err := GetByIDs(ctx, c, "age", []string{"1"}); err == nil {
+		t.Error("GetByIDs() with string keys for the int age succeeded, want an error")
+	}
+}
+
+func TestGetByIDs_chunked(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testUser{})
+	c := NewCRUD[testUser](db)
+	seedUsers(t, c, idChunkSize+10)
+
+	var queries int
+	if err := db.Callback().Query().After("gorm:query").Register("test:count", func(*gorm.DB) { queries++ }); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	ids := make([]uint, idChunkSize+20)
+	for i := range ids {
+		ids[i] = uint(i + 1)
+	}
+	got, err := GetByIDs(ctx, c, "id", ids)
+	if err != nil {
+		t.Fatalf("GetByIDs() error: %v", err)
+	}
+	if len(got) != idChunkSize+10 {
+		t.Errorf("GetByIDs() returned %d records, want %d", len(got), idChunkSize+10)
+	}
+	if queries != 2 {
+		t.Errorf("issued %d queries, want 2", queries)
+	}
+}
+
+type testCode string
+
+type testCountry struct {
+	Code testCode `gorm:"primaryKey"`
+	Name string
+}
+
+func TestGetByIDs_namedKeyType(t *testing.T) {
+	ctx := context.Background()
+	c := NewCRUD[testCountry](newTestDB(t, &testCountry{}))
+	if err := c.Create(ctx, &testCountry{Code: "NL", Name: "Netherlands"}, &testCountry{Code: "SE", Name: "Sweden"}); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	byCode, err := GetByIDs(ctx, c, "code", []string{"NL", "FR"})
+	if err != nil {
+		t.Fatalf("GetByIDs() error: %v", err)
+	}
+	if len(byCode) != 1 || byCode["NL"] == nil || byCode["NL"].Name != "Netherlands" {
+		t.Errorf("GetByIDs() = %v, want the Netherlands only", byCode)
+	}
+
+	byName, err := GetByIDs(ctx, c, "name", []testCode{"Sweden"})
+	if err != nil {
+		t.Fatalf("GetByIDs(name) error: %v", err)
+	}
+	if len(byName) != 1 || byName["Sweden"] == nil || byName["Sweden"].Code != "SE" {
+		t.Errorf("GetByIDs(name) = %v, want Sweden only", byName)
+	}
+}
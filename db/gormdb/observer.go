@@ -0,0 +1,88 @@
+package gormdb
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Observer is notified after each Create, Get, List, Update and Delete with the operation name,
+// its duration and error, e.g. to feed Prometheus histograms and error counters.
+type Observer interface {
+	Observe(ctx context.Context, operation string, duration time.Duration, err error)
+}
+
+// ObserverFunc adapts a function to Observer
+type ObserverFunc func(ctx context.Context, operation string, duration time.Duration, err error)
+
+func (f ObserverFunc) Observe(ctx context.Context, operation string, duration time.Duration, err error) {
+	f(ctx, operation, duration, err)
+}
+
+var _ CRUD[struct{}] = (*observedCRUD[struct{}])(nil)
+
+// observedCRUD decorates a CRUD, reporting its main operations to an Observer
+type observedCRUD[T any] struct {
+	CRUD[T]
+	observer Observer
+}
+
+// WithObserver returns c with every Create, Get, List, Update and Delete reported to o
+func WithObserver[T any](c CRUD[T], o Observer) CRUD[T] {
+	return &observedCRUD[T]{CRUD: c, observer: o}
+}
+
+func (c *observedCRUD[T]) observe(ctx context.Context, operation string, start time.Time, err error) {
+	c.observer.Observe(ctx, operation, time.Since(start), err)
+}
+
+// conn keeps the package functions like PluckColumn working on the decorated CRUD
+func (c *observedCRUD[T]) conn(ctx context.Context) *gorm.DB {
+	if p, ok := c.CRUD.(connProvider); ok {
+		return p.conn(ctx)
+	}
+	return nil
+}
+
+func (c *observedCRUD[T]) buildOpt(opts ...QueryOptFn) *QueryOpt {
+	if p, ok := c.CRUD.(connProvider); ok {
+		return p.buildOpt(opts...)
+	}
+	return BuildOpt(opts...)
+}
+
+func (c *observedCRUD[T]) Create(ctx context.Context, entities ...*T) error {
+	start := time.Now()
+	err := c.CRUD.Create(ctx, entities...)
+	c.observe(ctx, "Create", start, err)
+	return err
+}
+
+func (c *observedCRUD[T]) Get(ctx context.Context, query *Query, opts ...QueryOptFn) (*T, error) {
+	start := time.Now()
+	result, err := c.CRUD.Get(ctx, query, opts...)
+	c.observe(ctx, "Get", start, err)
+	return result, err
+}
+
+func (c *observedCRUD[T]) List(ctx context.Context, query *Query, opts ...QueryOptFn) (*ListRes[T], error) {
+	start := time.Now()
+	result, err := c.CRUD.List(ctx, query, opts...)
+	c.observe(ctx, "List", start, err)
+	return result, err
+}
+
+func (c *observedCRUD[T]) Update(ctx context.Context, query *Query, uParam map[string]any) (int64, error) {
+	start := time.Now()
+	affected, err := c.CRUD.Update(ctx, query, uParam)
+	c.observe(ctx, "Update", start, err)
+	return affected, err
+}
+
+func (c *observedCRUD[T]) Delete(ctx context.Context, query *Query, opts ...QueryOptFn) (int64, error) {
+	start := time.Now()
+	affected, err := c.CRUD.Delete(ctx, query, opts...)
+	c.observe(ctx, "Delete", start, err)
+	return affected, err
+}
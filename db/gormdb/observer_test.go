@@ -0,0 +1,62 @@
+package gormdb
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type observation struct {
+	err       error
+	operation string
+}
+
+func TestWithObserver(t *testing.T) {
+	ctx := context.Background()
+
+	var observed []observation
+	c := WithObserver(NewCRUD[testUser](newTestDB(t, &testUser{})), ObserverFunc(
+		func(_ context.Context, operation string, duration time.Duration, err error) {
+			if duration <= 0 {
+				t.Errorf("%s observed with duration %v", operation, duration)
+			}
+			observed = append(observed, observation{operation: operation, err: err})
+		}))
+
+	byID := Q(map[string]any{"id": 1})
+	_ = c.Create(ctx, &testUser{Name: "a"})
+	_, _ = c.Get(ctx, byID)
+	_, _ = c.List(ctx, Q(nil))
+	_, _ = c.Update(ctx, byID, map[string]any{"age": 3})
+	_, _ = c.Delete(ctx, byID)
+	_, missingErr := c.Get(ctx, byID)
+
+	// Operations outside the observed set are not reported
+	_, _ = c.Count(ctx, Q(nil))
+
+	wantOps := []string{"Create", "Get", "List", "Update", "Delete", "Get"}
+	gotOps := make([]string, len(observed))
+	for i, o := range observed {
+		gotOps[i] = o.operation
+	}
+	if !slices.Equal(gotOps, wantOps) {
+		t.Fatalf("observed %v, want %v", gotOps, wantOps)
+	}
+
+	for _, o := range observed[:5] {
+		if o.err != nil {
+			t.Errorf("%s observed error %v, want nil", o.operation, o.err)
+		}
+	}
+	if last := observed[5]; !errors.Is(last.err, gorm.ErrRecordNotFound) || last.err != missingErr {
+		t.Errorf("missing Get observed error %v, want the returned %v", last.err, missingErr)
+	}
+
+	if _, err := PluckColumn[string](ctx, c, Q(nil), "name"); err != nil {
+		t.Errorf("PluckColumn() on an observed CRUD error: %v", err)
+	}
+}
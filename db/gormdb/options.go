@@ -0,0 +1,90 @@
+package gormdb
+
+import (
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CRUDOption configures a CRUD built by NewCRUDWithOpts
+type CRUDOption func(*crudOptions)
+
+type crudOptions struct {
+	observer Observer
+	defaults []QueryOptFn
+	pageSize int
+}
+
+// NewCRUDWithOpts is like NewCRUD with defaults set by opts for every operation.
+//
+// Example:
+//
+//	users := NewCRUDWithOpts[User](db, WithDefaultTimeout(3*time.Second), WithDefaultPageSize(20))
+func NewCRUDWithOpts[T any](db *gorm.DB, opts ...CRUDOption) CRUD[T] {
+	o := &crudOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var c CRUD[T] = &crud[T]{DB: db, defaults: o.defaults, pageSize: o.pageSize}
+	if o.observer != nil {
+		c = WithObserver(c, o.observer)
+	}
+
+	return c
+}
+
+// WithDefaults applies opts before the options of every call, which override them.
+// Calls taking no options, like Exists, Update and Sync, and the package functions but Raw
+// and RawOne still get the scoping ones, Unscoped and Scope; Raw and RawOne run their SQL as is.
+func WithDefaults(opts ...QueryOptFn) CRUDOption {
+	return func(o *crudOptions) {
+		o.defaults = append(o.defaults, opts...)
+	}
+}
+
+// WithDefaultTimeout bounds every Get, List, Count and Stream call to d unless it passes its own Timeout
+func WithDefaultTimeout(d time.Duration) CRUDOption {
+	return WithDefaults(Timeout(d))
+}
+
+// WithDefaultPageSize paginates List by size records when the call passes no Pagination.
+// Pagination without a size and Cursor use size as well.
+func WithDefaultPageSize(size int) CRUDOption {
+	return func(o *crudOptions) {
+		if size < 1 {
+			return
+		}
+		o.pageSize = size
+		o.defaults = append(o.defaults, func(c *QueryOpt) *QueryOpt {
+			c.PageSize = size
+			return c
+		})
+	}
+}
+
+// WithDefaultUnscoped includes soft-deleted records in every call but Raw and RawOne, see Unscoped.
+// Calls can't opt out of it.
+func WithDefaultUnscoped() CRUDOption {
+	return WithDefaults(Unscoped())
+}
+
+// WithCRUDObserver reports the operations of the CRUD to ob, see WithObserver
+func WithCRUDObserver(ob Observer) CRUDOption {
+	return func(o *crudOptions) {
+		o.observer = ob
+	}
+}
+
+// buildOpt is BuildOpt with the defaults of r applied first
+func (r *crud[T]) buildOpt(opts ...QueryOptFn) *QueryOpt {
+	o := BuildOpt(slices.Concat(r.defaults, opts)...)
+
+	// A default page size paginates List unless the call pages by cursor
+	if r.pageSize > 0 && !o.UseCursor {
+		o.Paginate = true
+	}
+
+	return o
+}
@@ -0,0 +1,53 @@
+package gormdb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// retryBackoff is the pause before the first retry of TransactionWithRetry, doubled after each attempt
+const retryBackoff = 10 * time.Millisecond
+
+func (r *crud[T]) TransactionWithRetry(ctx context.Context, attempts int, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txCtxKey{}).(*gorm.DB); ok {
+		attempts = 1
+	}
+
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := r.Transaction(ctx, fn)
+		if err == nil || attempt >= attempts || !isRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isRetryable reports whether err is a deadlock or serialization failure, after which
+// running the transaction again can succeed
+func isRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// ER_LOCK_DEADLOCK
+		return mysqlErr.Number == 1213
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// serialization_failure and deadlock_detected
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package gormdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestTransactionWithRetry(t *testing.T) {
+	ctx := context.Background()
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	t.Run("succeeds after retryable failures", func(t *testing.T) {
+		c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+
+		calls := 0
+		err := c.TransactionWithRetry(ctx, 3, func(ctx context.Context) error {
+			calls++
+			if err := c.Create(ctx, &testUser{Name: "attempt"}); err != nil {
+				return err
+			}
+			if calls < 3 {
+				return deadlock
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("TransactionWithRetry() error: %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("fn called %d times, want 3", calls)
+		}
+		// The failed attempts were rolled back
+		if n, _ := c.Count(ctx, Q(nil)); n != 1 {
+			t.Errorf("%d users created, want 1", n)
+		}
+	})
+
+	tests := []struct {
+		name      string
+		attempts  int
+		err       error
+		wantCalls int
+	}{
+		{name: "attempts exhausted", attempts: 2, err: deadlock, wantCalls: 2},
+		{name: "postgres serialization failure", attempts: 2, err: &pgconn.PgError{Code: "40001"}, wantCalls: 2},
+		{name: "postgres deadlock", attempts: 3, err: &pgconn.PgError{Code: "40P01"}, wantCalls: 3},
+		{name: "not retryable", attempts: 3, err: errors.New("boom"), wantCalls: 1},
+		{name: "other mysql error", attempts: 3, err: &mysql.MySQLError{Number: 1062}, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+
+			calls := 0
+			err := c.TransactionWithRetry(ctx, tt.attempts, func(context.Context) error {
+				calls++
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("TransactionWithRetry() error = %v, want %v", err, tt.err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+
+	t.Run("cancelled context stops retries", func(t *testing.T) {
+		c := NewCRUD[testUser](newTestDB(t, &testUser{}))
+		ctx, cancel := context.WithCancel(ctx)
+
+		calls := 0
+		err := c.TransactionWithRetry(ctx, 5, func(context.Context) error {
+			calls++
+			cancel()
+			return deadlock
+		})
+		if !errors.Is(err, context.Canceled) || calls != 1 {
+			t.Errorf("TransactionWithRetry() = %v after %d calls, want %v after 1", err, calls, context.Canceled)
+		}
+	})
+}
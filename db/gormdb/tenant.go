@@ -0,0 +1,127 @@
+package gormdb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tenantFilter restricts every statement of a crud to the records of one tenant
+type tenantFilter struct {
+	id     any
+	column string
+}
+
+// NewTenantCRUD is like NewCRUD but every Get, List, Count, Update, Delete and the other calls only
+// see and modify the records whose tenantColumn equals tenantID, AND-ed with the query conditions.
+// Create, CreateInBatches, Upsert and FirstOrCreate set tenantColumn to tenantID, and updates
+// can't move records to another tenant.
+//
+// Transactions started by a tenant CRUD aren't filtered themselves, so other CRUDs can join them.
+func NewTenantCRUD[T any](db *gorm.DB, tenantColumn string, tenantID any) CRUD[T] {
+	return &crud[T]{DB: db, tenant: &tenantFilter{column: tenantColumn, id: tenantID}}
+}
+
+// cond matches the records of the tenant in the table of the statement
+func (f *tenantFilter) cond() clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.column}, Value: f.id}
+}
+
+// stamp sets the tenant column of the entities to the tenant of r
+func (r *crud[T]) stamp(ctx context.Context, entities ...*T) error {
+	if r.tenant == nil {
+		return nil
+	}
+
+	s, err := r.schema()
+	if err != nil {
+		return err
+	}
+	field := s.LookUpField(r.tenant.column)
+	if field == nil {
+		return fmt.Errorf("gormdb: %s has no tenant column %q", s.Name, r.tenant.column)
+	}
+
+	for _, entity := range entities {
+		if err := field.Set(ctx, reflect.ValueOf(entity), r.tenant.id); err != nil {
+			return fmt.Errorf("gormdb: set tenant column %q: %w", r.tenant.column, err)
+		}
+	}
+
+	return nil
+}
+
+// checkUpdate refuses updates that would move records to another tenant
+func (r *crud[T]) checkUpdate(uParam map[string]any) error {
+	if r.tenant == nil {
+		return nil
+	}
+
+	s, err := r.schema()
+	if err != nil {
+		return err
+	}
+
+	for key := range uParam {
+		if key == r.tenant.column {
+			return fmt.Errorf("gormdb: tenant column %q can't be updated", key)
+		}
+		if field := s.LookUpField(key); field != nil && field.DBName == r.tenant.column {
+			return fmt.Errorf("gormdb: tenant column %q can't be updated", key)
+		}
+	}
+
+	return nil
+}
+
+// tenantOnConflict is the ON CONFLICT clause of Upsert for a tenant CRUD. It updates the columns
+// UpdateAll would except the tenant column, and never overwrites the conflicting record of
+// another tenant: with a conflict WHERE, or as MySQL's ON DUPLICATE KEY UPDATE has none,
+// with assignments keeping the current values when the tenant differs.
+func (r *crud[T]) tenantOnConflict(conflictColumns []clause.Column) (clause.OnConflict, error) {
+	s, err := r.schema()
+	if err != nil {
+		return clause.OnConflict{}, err
+	}
+
+	skip := map[string]bool{r.tenant.column: true}
+	for _, col := range conflictColumns {
+		skip[col.Name] = true
+	}
+
+	var columns []string
+	for _, field := range s.Fields {
+		// The fields gorm's UpdateAll leaves out, plus the tenant and conflict columns
+		if field.DBName == "" || !field.Creatable || field.PrimaryKey || field.AutoCreateTime > 0 || skip[field.DBName] {
+			continue
+		}
+		if field.HasDefaultValue && field.DefaultValueInterface == nil && !strings.EqualFold(field.DefaultValue, "NULL") {
+			continue
+		}
+		columns = append(columns, field.DBName)
+	}
+
+	onConflict := clause.OnConflict{Columns: conflictColumns}
+	switch {
+	case len(columns) == 0:
+		onConflict.DoNothing = true
+	case r.DB.Dialector.Name() == "mysql":
+		tenantColumn := clause.Column{Name: r.tenant.column}
+		for _, name := range columns {
+			column := clause.Column{Name: name}
+			onConflict.DoUpdates = append(onConflict.DoUpdates, clause.Assignment{
+				Column: column,
+				Value:  clause.Expr{SQL: "IF(? = ?, VALUES(?), ?)", Vars: []any{tenantColumn, r.tenant.id, column, column}},
+			})
+		}
+	default:
+		onConflict.DoUpdates = clause.AssignmentColumns(columns)
+		onConflict.Where = clause.Where{Exprs: []clause.Expression{r.tenant.cond()}}
+	}
+
+	return onConflict, nil
+}
@@ -0,0 +1,199 @@
+package gormdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type testTenantDoc struct {
+	Title    string
+	ID       uint `gorm:"primaryKey"`
+	TenantID uint
+	Version  int
+}
+
+func TestNewTenantCRUD(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testTenantDoc{})
+
+	tenantA := NewTenantCRUD[testTenantDoc](db, "tenant_id", 1)
+	tenantB := NewTenantCRUD[testTenantDoc](db, "tenant_id", 2)
+
+	// Create stamps the tenant whatever the entity says
+	aDocs := []*testTenantDoc{{Title: "a1"}, {Title: "a2", TenantID: 2}}
+	if err := tenantA.Create(ctx, aDocs...); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	bDoc := &testTenantDoc{Title: "b1"}
+	if err := tenantB.CreateInBatches(ctx, []*testTenantDoc{bDoc}, 10); err != nil {
+		t.Fatalf("CreateInBatches() error: %v", err)
+	}
+	for _, d := range aDocs {
+		if d.TenantID != 1 {
+			t.Errorf("Create() stamped tenant %d on %q, want 1", d.TenantID, d.Title)
+		}
+	}
+
+	res, err := tenantA.List(ctx, Q(nil), Pagination(1, 10))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if res.Total != 2 || len(res.Items) != 2 {
+		t.Errorf("List() = %d items, total %d, want the 2 docs of tenant 1", len(res.Items), res.Total)
+	}
+	for _, d := range res.Items {
+		if d.TenantID != 1 {
+			t.Errorf("List() returned %+v of another tenant", d)
+		}
+	}
+
+	if _, err := tenantA.GetByID(ctx, bDoc.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID() of another tenant's doc error = %v, want %v", err, ErrNotFound)
+	}
+	if n, err := tenantA.Count(ctx, Q(map[string]any{"title": "b1"})); err != nil || n != 0 {
+		t.Errorf("Count() of another tenant's docs = %d, %v, want 0", n, err)
+	}
+	if ok, err := tenantA.Exists(ctx, Q(map[string]any{"id": bDoc.ID})); err != nil || ok {
+		t.Errorf("Exists() of another tenant's doc = %v, %v, want false", ok, err)
+	}
+
+	// Writes through tenant A never reach tenant B's rows
+	if n, err := tenantA.Update(ctx, Q(nil).Gt("id", 0), map[string]any{"title": "hacked"}); err != nil || n != 2 {
+		t.Errorf("Update() = %d, %v, want only the 2 docs of tenant 1", n, err)
+	}
+	if n, err := tenantA.UpdateByIDs(ctx, []any{bDoc.ID}, map[string]any{"title": "hacked"}); err != nil || n != 0 {
+		t.Errorf("UpdateByIDs() of another tenant's doc = %d, %v, want 0", n, err)
+	}
+	if _, err := tenantA.UpdateReturning(ctx, Q(map[string]any{"id": bDoc.ID}), map[string]any{"title": "hacked"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateReturning() of another tenant's doc error = %v, want %v", err, ErrNotFound)
+	}
+	err = tenantA.UpdateByFn(ctx, Q(map[string]any{"id": bDoc.ID}), func(*testTenantDoc) (bool, error) { return true, nil })
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateByFn() of another tenant's doc error = %v, want %v", err, ErrNotFound)
+	}
+	if n, err := tenantA.Delete(ctx, Q(map[string]any{"id": bDoc.ID})); err != nil || n != 0 {
+		t.Errorf("Delete() of another tenant's doc = %d, %v, want 0", n, err)
+	}
+
+	if err := tenantA.Upsert(ctx, []string{"id"}, &testTenantDoc{ID: bDoc.ID, Title: "hacked"}); err != nil {
+		t.Errorf("Upsert() conflicting with another tenant's doc error: %v", err)
+	}
+
+	// Records can't be moved to another tenant
+	if _, err := tenantA.Update(ctx, Q(nil).Gt("id", 0), map[string]any{"tenant_id": 2}); err == nil {
+		t.Error("Update() of the tenant column should fail")
+	}
+	err = tenantA.UpdateByFn(ctx, Q(map[string]any{"id": aDocs[0].ID}), func(d *testTenantDoc) (bool, error) {
+		d.Title, d.TenantID = "moved", 2
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateByFn() error: %v", err)
+	}
+
+	got, err := tenantB.List(ctx, Q(nil))
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(got.Items) != 1 || *got.Items[0] != *bDoc {
+		t.Errorf("tenant 2 docs = %+v, want only the untouched %+v", got.Items, bDoc)
+	}
+
+	// FirstOrCreate looks up and creates within the tenant
+	doc, created, err := tenantB.FirstOrCreate(ctx, Q(map[string]any{"title": "moved"}), nil)
+	if err != nil {
+		t.Fatalf("FirstOrCreate() error: %v", err)
+	}
+	if !created || doc.TenantID != 2 {
+		t.Errorf("FirstOrCreate() = %+v, created=%v, want a new doc of tenant 2", doc, created)
+	}
+}
+
+func TestNewTenantCRUD_transaction(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &testTenantDoc{}, &testUser{})
+
+	docs := NewTenantCRUD[testTenantDoc](db, "tenant_id", 1)
+	users := NewCRUD[testUser](db)
+
+	// A CRUD without a tenant column joins the transaction of a tenant CRUD unfiltered
+	err := docs.Transaction(ctx, func(ctx context.Context) error {
+		if err := docs.Create(ctx, &testTenantDoc{Title: "a"}); err != nil {
+			return err
+		}
+		if err := users.Create(ctx, &testUser{Name: "u"}); err != nil {
+			return err
+		}
+		_, err := users.Get(ctx, Q(map[string]any{"name": "u"}))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error: %v", err)
+	}
+}
+
+// upsertSQL returns the statement a tenant Upsert generates on db, without running it
+func upsertSQL(t *testing.T, db *gorm.DB) (string, []any) {
+	t.Helper()
+
+	var (
+		sql  string
+		vars []any
+	)
+	err := db.Callback().Create().After("gorm:create").Register("test:upsert_sql", func(tx *gorm.DB) {
+		sql, vars = tx.Statement.SQL.String(), tx.Statement.Vars
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	c := NewTenantCRUD[testTenantDoc](db, "tenant_id", 7)
+	if err := c.Upsert(context.Background(), []string{"id"}, &testTenantDoc{ID: 1, Title: "t"}); err != nil {
+		t.Fatalf("Upsert() error: %v", err)
+	}
+
+	return sql, vars
+}
+
+func TestNewTenantCRUD_upsertMySQL(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:1)/db", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open mysql dry run: %v", err)
+	}
+
+	sql, vars := upsertSQL(t, db)
+
+	if !strings.Contains(sql, "ON DUPLICATE KEY UPDATE") {
+		t.Fatalf("SQL %q is not a MySQL upsert", sql)
+	}
+	for _, col := range []string{"title", "version"} {
+		want := fmt.Sprintf("`%s`=IF(`tenant_id` = ?, VALUES(`%s`), `%s`)", col, col, col)
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q does not guard %s by tenant, want %s", sql, col, want)
+		}
+	}
+	if update := sql[strings.Index(sql, "UPDATE"):]; strings.Contains(update, "`tenant_id`=") {
+		t.Errorf("SQL %q updates the tenant column", sql)
+	}
+	if !slices.Contains(vars, any(7)) {
+		t.Errorf("vars %v do not hold the tenant id", vars)
+	}
+}
+
+func TestNewTenantCRUD_upsertSQLite(t *testing.T) {
+	sql, _ := upsertSQL(t, newTestDB(t, &testTenantDoc{}).Session(&gorm.Session{DryRun: true}))
+
+	update := sql[strings.Index(sql, "DO UPDATE"):]
+	if strings.Contains(update, "`tenant_id`=") || !strings.Contains(update, "WHERE `test_tenant_docs`.`tenant_id` = ?") {
+		t.Errorf("SQL %q must not update the tenant column and must guard by tenant", sql)
+	}
+}
@@ -1,6 +1,9 @@
 package db
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/downtoyonder/dry-go/config"
@@ -14,26 +17,106 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// ErrInvalidConfig is returned when the config given to NewDBErr can't describe a database
+var ErrInvalidConfig = errors.New("db: invalid config")
+
 // 一次性临时数据库
 var OneOffDB = _OneOffDB{}
 
 type _OneOffDB struct{}
 
+// OneOffOption overrides a setting of a OneOffDB, which default to debug logging and the NewDBErr pool
+type OneOffOption func(conf map[string]any)
+
+// OneOffDebug turns the debug logging of every statement on or off
+func OneOffDebug(enabled bool) OneOffOption {
+	return OneOffSetting("debug", enabled)
+}
+
+// OneOffPool sets the max_idle_conns and max_open_conns of the connection pool
+func OneOffPool(maxIdle, maxOpen int) OneOffOption {
+	return func(conf map[string]any) {
+		conf["max_idle_conns"] = maxIdle
+		conf["max_open_conns"] = maxOpen
+	}
+}
+
+// OneOffSetting sets any config key understood by NewDBErr, e.g. conn_max_lifetime
+func OneOffSetting(key string, value any) OneOffOption {
+	return func(conf map[string]any) {
+		conf[key] = value
+	}
+}
+
 func (o _OneOffDB) MySQL(dsn string) *gorm.DB {
+	return o.open("mysql", dsn)
+}
+
+// MySQLWith is like MySQL with settings changed by opts, e.g. a quiet, larger pool for load tests:
+//
+//	db := OneOffDB.MySQLWith(dsn, OneOffDebug(false), OneOffPool(50, 100))
+func (o _OneOffDB) MySQLWith(dsn string, opts ...OneOffOption) *gorm.DB {
+	return o.open("mysql", dsn, opts...)
+}
+
+func (o _OneOffDB) Postgres(dsn string) *gorm.DB {
+	return o.open("postgres", dsn)
+}
+
+// PostgresWith is like Postgres with settings changed by opts
+func (o _OneOffDB) PostgresWith(dsn string, opts ...OneOffOption) *gorm.DB {
+	return o.open("postgres", dsn, opts...)
+}
+
+func (o _OneOffDB) SQLite(dsn string) *gorm.DB {
+	return o.open("sqlite", dsn)
+}
+
+// SQLiteWith is like SQLite with settings changed by opts
+func (o _OneOffDB) SQLiteWith(dsn string, opts ...OneOffOption) *gorm.DB {
+	return o.open("sqlite", dsn, opts...)
+}
+
+// SQLiteMemory 打开共享缓存的内存数据库，同一进程内的所有调用共享同一个库
+func (o _OneOffDB) SQLiteMemory() *gorm.DB {
+	return o.open("sqlite", "file::memory:?cache=shared")
+}
+
+func (o _OneOffDB) open(driver, dsn string, opts ...OneOffOption) *gorm.DB {
 	if dsn == "" {
 		panic("dsn is empty")
 	}
 
-	c := config.NewViperFromMap(map[string]interface{}{
-		"driver": "mysql",
+	conf := map[string]interface{}{
+		"driver": driver,
 		"dsn":    dsn,
 		"debug":  true,
-	})
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
 
-	return NewDB(c, logger.Default)
+	return NewDB(config.NewViperFromMap(conf), logger.Default)
 }
 
-func NewDB(c *viper.Viper, l logger.Interface) *gorm.DB {
+// connectPing is the Ping of ping_on_connect, replaced in tests to simulate unreachable databases
+var connectPing = Ping
+
+// NewDB is like NewDBErr but panics on error.
+func NewDB(c *viper.Viper, l logger.Interface, opts ...Option) *gorm.DB {
+	db, err := NewDBErr(c, l, opts...)
+	utils.PanicErr(err)
+
+	return db
+}
+
+// NewDBErr opens a database configured by c with the keys:
+//   - driver: mysql, postgres or sqlite
+//   - dsn, debug, gorm_prepare_stmt, gorm_skip_default_tx
+//   - max_idle_conns, max_open_conns (default 30)
+//   - conn_max_idle_time, conn_max_lifetime (default 10m), e.g. "30s"
+//   - ping_on_connect: Ping the database before returning
+func NewDBErr(c *viper.Viper, l logger.Interface, opts ...Option) (*gorm.DB, error) {
 	const (
 		MYSQL    = "mysql"
 		POSTGRES = "postgres"
@@ -55,6 +138,10 @@ func NewDB(c *viper.Viper, l logger.Interface) *gorm.DB {
 		}
 	)
 
+	if dsn == "" {
+		return nil, fmt.Errorf("%w: dsn is empty", ErrInvalidConfig)
+	}
+
 	// GORM doc: https://gorm.io/docs/connecting_to_the_database.html
 	switch driver {
 	case MYSQL:
@@ -67,23 +154,123 @@ func NewDB(c *viper.Viper, l logger.Interface) *gorm.DB {
 	case SQLITE:
 		db, err = gorm.Open(sqlite.Open(dsn), gormCfg)
 	default:
-		panic("unknown db driver")
+		return nil, fmt.Errorf("%w: unknown driver %q", ErrInvalidConfig, driver)
 	}
 
-	utils.PanicErr(err)
+	if err != nil {
+		// gorm.Open also fails when its initial ping does, leaving the pool it opened to us
+		if db != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				_ = sqlDB.Close()
+			}
+		}
+		return nil, fmt.Errorf("db: open %s: %w", driver, err)
+	}
 
 	// Connection Pool config
 	sqlDB, err := db.DB()
-	utils.PanicErr(err)
+	if err != nil {
+		return nil, fmt.Errorf("db: get connection pool: %w", err)
+	}
+
+	sqlDB.SetMaxIdleConns(getIntOr(c, "max_idle_conns", 30))
+	sqlDB.SetMaxOpenConns(getIntOr(c, "max_open_conns", 30))
+	sqlDB.SetConnMaxIdleTime(getDurationOr(c, "conn_max_idle_time", 10*time.Minute))
+	sqlDB.SetConnMaxLifetime(getDurationOr(c, "conn_max_lifetime", 10*time.Minute))
+
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
 
-	sqlDB.SetMaxIdleConns(30)
-	sqlDB.SetMaxOpenConns(30)
-	sqlDB.SetConnMaxIdleTime(10 * time.Minute)
-	sqlDB.SetConnMaxLifetime(10 * time.Minute)
+	// Past this point the pool is open, close it on failure so it doesn't leak
+	if o.tracer != nil {
+		if err := UseTracer(db, o.tracer); err != nil {
+			_ = sqlDB.Close()
+			return nil, err
+		}
+	}
+
+	if c.GetBool("ping_on_connect") {
+		if err := connectPing(context.Background(), db); err != nil {
+			_ = sqlDB.Close()
+			return nil, err
+		}
+	}
 
 	if enableDebug {
 		db = db.Debug()
 	}
 
-	return db
+	return db, nil
+}
+
+// NewDBWithRetry opens the database like NewDBErr and pings it, retrying up to attempts times
+// with an exponential backoff starting at backoff, for databases that aren't ready yet at startup.
+// Invalid configs are not retried and ctx cancellation stops the retries.
+func NewDBWithRetry(ctx context.Context, c *viper.Viper, l logger.Interface, attempts int, backoff time.Duration, opts ...Option) (*gorm.DB, error) {
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		var db *gorm.DB
+		if db, err = NewDBErr(c, l, opts...); err == nil {
+			if err = Ping(ctx, db); err == nil {
+				return db, nil
+			}
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				_ = sqlDB.Close()
+			}
+		}
+
+		if errors.Is(err, ErrInvalidConfig) || attempt >= attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	return nil, fmt.Errorf("db: connect failed after %d attempts: %w", attempts, err)
+}
+
+// AutoMigrate creates or updates the tables of models, e.g. to bootstrap a OneOffDB schema.
+func AutoMigrate(db *gorm.DB, models ...any) error {
+	if err := db.AutoMigrate(models...); err != nil {
+		return fmt.Errorf("db: auto migrate: %w", err)
+	}
+
+	return nil
+}
+
+// Ping verifies the database behind db is reachable.
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("db: get connection pool: %w", err)
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("db: ping: %w", err)
+	}
+
+	return nil
+}
+
+func getIntOr(c *viper.Viper, key string, def int) int {
+	if !c.IsSet(key) {
+		return def
+	}
+	return c.GetInt(key)
+}
+
+// getDurationOr accepts durations as strings like "5m" or as nanoseconds
+func getDurationOr(c *viper.Viper, key string, def time.Duration) time.Duration {
+	if !c.IsSet(key) {
+		return def
+	}
+	return c.GetDuration(key)
 }
@@ -0,0 +1,349 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/downtoyonder/dry-go/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// poolSettings reads the pool limits sql.DB doesn't expose through Stats
+func poolSettings(sqlDB *sql.DB) (maxIdle int64, maxIdleTime, maxLifetime time.Duration) {
+	v := reflect.ValueOf(sqlDB).Elem()
+	return v.FieldByName("maxIdleCount").Int(),
+		time.Duration(v.FieldByName("maxIdleTime").Int()),
+		time.Duration(v.FieldByName("maxLifetime").Int())
+}
+
+func TestNewDB_pool(t *testing.T) {
+	tests := []struct {
+		name            string
+		conf            map[string]any
+		wantMaxOpen     int
+		wantMaxIdle     int64
+		wantMaxIdleTime time.Duration
+		wantMaxLifetime time.Duration
+	}{
+		{
+			name:            "defaults",
+			conf:            map[string]any{},
+			wantMaxOpen:     30,
+			wantMaxIdle:     30,
+			wantMaxIdleTime: 10 * time.Minute,
+			wantMaxLifetime: 10 * time.Minute,
+		},
+		{
+			name: "configured",
+			conf: map[string]any{
+				"max_open_conns":     5,
+				"max_idle_conns":     2,
+				"conn_max_idle_time": "30s",
+				"conn_max_lifetime":  "1h",
+			},
+			wantMaxOpen:     5,
+			wantMaxIdle:     2,
+			wantMaxIdleTime: 30 * time.Second,
+			wantMaxLifetime: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.NewViperFromMap(map[string]any{
+				"driver": "sqlite",
+				"dsn":    filepath.Join(t.TempDir(), "test.db"),
+			}, tt.conf)
+
+			sqlDB, err := NewDB(c, logger.Discard).DB()
+			if err != nil {
+				t.Fatalf("DB() error: %v", err)
+			}
+			defer sqlDB.Close()
+
+			if got := sqlDB.Stats().MaxOpenConnections; got != tt.wantMaxOpen {
+				t.Errorf("MaxOpenConnections = %d, want %d", got, tt.wantMaxOpen)
+			}
+			maxIdle, maxIdleTime, maxLifetime := poolSettings(sqlDB)
+			if maxIdle != tt.wantMaxIdle {
+				t.Errorf("max idle conns = %d, want %d", maxIdle, tt.wantMaxIdle)
+			}
+			if maxIdleTime != tt.wantMaxIdleTime {
+				t.Errorf("conn max idle time = %v, want %v", maxIdleTime, tt.wantMaxIdleTime)
+			}
+			if maxLifetime != tt.wantMaxLifetime {
+				t.Errorf("conn max lifetime = %v, want %v", maxLifetime, tt.wantMaxLifetime)
+			}
+		})
+	}
+}
+
+func TestNewDBErr(t *testing.T) {
+	tests := []struct {
+		name string
+		conf map[string]any
+		want string
+	}{
+		{name: "unknown driver", conf: map[string]any{"driver": "oracle", "dsn": "x"}, want: `unknown driver "oracle"`},
+		{name: "missing driver", conf: map[string]any{"dsn": "x"}, want: `unknown driver ""`},
+		{name: "empty dsn", conf: map[string]any{"driver": "sqlite", "dsn": ""}, want: "dsn is empty"},
+		{name: "missing dsn", conf: map[string]any{"driver": "mysql"}, want: "dsn is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := NewDBErr(config.NewViperFromMap(tt.conf), logger.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewDBErr() = %v, %v; want error containing %q", db, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("NewDB panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("NewDB() with an unknown driver should panic")
+			}
+		}()
+		NewDB(config.NewViperFromMap(map[string]any{"driver": "oracle", "dsn": "x"}), logger.Discard)
+	})
+}
+
+func TestPing(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB(config.NewViperFromMap(map[string]any{
+		"driver":          "sqlite",
+		"dsn":             "file::memory:",
+		"ping_on_connect": true,
+	}), logger.Discard)
+
+	if err := Ping(ctx, db); err != nil {
+		t.Fatalf("Ping() error: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("DB() error: %v", err)
+	}
+	if err := sqlDB.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	if err := Ping(ctx, db); err == nil {
+		t.Error("Ping() on a closed DB should fail")
+	}
+}
+
+// failConnectPing makes ping_on_connect fail, collecting the DBs it was given
+func failConnectPing(t *testing.T) *[]*gorm.DB {
+	t.Helper()
+
+	var pinged []*gorm.DB
+	connectPing = func(_ context.Context, db *gorm.DB) error {
+		pinged = append(pinged, db)
+		return errors.New("unreachable")
+	}
+	t.Cleanup(func() { connectPing = Ping })
+
+	return &pinged
+}
+
+// assertClosed fails unless the pool behind every db has been closed
+func assertClosed(t *testing.T, dbs []*gorm.DB) {
+	t.Helper()
+
+	for i, db := range dbs {
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatalf("DB() error: %v", err)
+		}
+		if err := sqlDB.Ping(); err == nil || !strings.Contains(err.Error(), "closed") {
+			t.Errorf("pool %d is still open after the failed connect (ping error %v)", i, err)
+		}
+	}
+}
+
+func TestNewDBErr_closesOnFailure(t *testing.T) {
+	pinged := failConnectPing(t)
+
+	c := config.NewViperFromMap(map[string]any{
+		"driver":          "sqlite",
+		"dsn":             filepath.Join(t.TempDir(), "test.db"),
+		"ping_on_connect": true,
+	})
+	if _, err := NewDBErr(c, logger.Discard); err == nil {
+		t.Fatal("NewDBErr() succeeded, want the ping error")
+	}
+	if len(*pinged) != 1 {
+		t.Fatalf("pinged %d times, want 1", len(*pinged))
+	}
+	assertClosed(t, *pinged)
+}
+
+func TestNewDBWithRetry_closesFailedAttempts(t *testing.T) {
+	pinged := failConnectPing(t)
+
+	c := config.NewViperFromMap(map[string]any{
+		"driver":          "sqlite",
+		"dsn":             filepath.Join(t.TempDir(), "test.db"),
+		"ping_on_connect": true,
+	})
+	if _, err := NewDBWithRetry(context.Background(), c, logger.Discard, 3, time.Millisecond); err == nil {
+		t.Fatal("NewDBWithRetry() succeeded, want the ping error")
+	}
+	if len(*pinged) != 3 {
+		t.Fatalf("made %d attempts, want 3", len(*pinged))
+	}
+	assertClosed(t, *pinged)
+}
+
+func TestNewDBWithRetry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("becomes available", func(t *testing.T) {
+		// sqlite can't create a database in a missing directory, so the DSN becomes valid once it exists
+		dir := filepath.Join(t.TempDir(), "later")
+		c := config.NewViperFromMap(map[string]any{"driver": "sqlite", "dsn": filepath.Join(dir, "test.db")})
+
+		go func() {
+			time.Sleep(120 * time.Millisecond)
+			_ = os.Mkdir(dir, 0o750)
+		}()
+
+		start := time.Now()
+		db, err := NewDBWithRetry(ctx, c, logger.Discard, 6, 50*time.Millisecond)
+		if err != nil {
+			t.Fatalf("NewDBWithRetry() error: %v", err)
+		}
+		if sqlDB, err := db.DB(); err == nil {
+			defer sqlDB.Close()
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("connected after %v, want at least one retry", elapsed)
+		}
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		c := config.NewViperFromMap(map[string]any{"driver": "sqlite", "dsn": filepath.Join(t.TempDir(), "missing", "test.db")})
+
+		if _, err := NewDBWithRetry(ctx, c, logger.Discard, 2, time.Millisecond); err == nil || !strings.Contains(err.Error(), "after 2 attempts") {
+			t.Errorf("NewDBWithRetry() error = %v, want failure after 2 attempts", err)
+		}
+	})
+
+	t.Run("invalid config is not retried", func(t *testing.T) {
+		c := config.NewViperFromMap(map[string]any{"driver": "oracle", "dsn": "x"})
+
+		start := time.Now()
+		if _, err := NewDBWithRetry(ctx, c, logger.Discard, 5, time.Second); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("NewDBWithRetry() error = %v, want %v", err, ErrInvalidConfig)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("invalid config took %v, want no backoff", elapsed)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		c := config.NewViperFromMap(map[string]any{"driver": "sqlite", "dsn": filepath.Join(t.TempDir(), "missing", "test.db")})
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		if _, err := NewDBWithRetry(ctx, c, logger.Discard, 10, time.Second); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("NewDBWithRetry() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}
+
+// closeOnCleanup closes the pool behind db when the test ends
+func closeOnCleanup(t *testing.T, db *gorm.DB) *gorm.DB {
+	t.Helper()
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("DB() error: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	return db
+}
+
+func TestOneOffDB(t *testing.T) {
+	tests := []struct {
+		name string
+		open func(t *testing.T) *gorm.DB
+	}{
+		{name: "SQLite", open: func(t *testing.T) *gorm.DB { return OneOffDB.SQLite(filepath.Join(t.TempDir(), "test.db")) }},
+		{name: "SQLiteMemory", open: func(*testing.T) *gorm.DB { return OneOffDB.SQLiteMemory() }},
+		{name: "Postgres", open: func(t *testing.T) *gorm.DB {
+			dsn := os.Getenv("DRY_GO_POSTGRES_DSN")
+			if dsn == "" {
+				t.Skip("DRY_GO_POSTGRES_DSN not set")
+			}
+			return OneOffDB.Postgres(dsn)
+		}},
+		{name: "MySQL", open: func(t *testing.T) *gorm.DB {
+			dsn := os.Getenv("DRY_GO_MYSQL_DSN")
+			if dsn == "" {
+				t.Skip("DRY_GO_MYSQL_DSN not set")
+			}
+			return OneOffDB.MySQL(dsn)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := closeOnCleanup(t, tt.open(t))
+
+			var one int
+			if err := db.Raw("SELECT 1").Scan(&one).Error; err != nil || one != 1 {
+				t.Errorf("SELECT 1 = %d, %v; want 1", one, err)
+			}
+		})
+	}
+}
+
+func TestOneOffDB_with(t *testing.T) {
+	if db := closeOnCleanup(t, OneOffDB.SQLite(filepath.Join(t.TempDir(), "debug.db"))); db.Logger == logger.Default {
+		t.Error("SQLite() logger is not in debug mode")
+	}
+
+	db := closeOnCleanup(t, OneOffDB.SQLiteWith(filepath.Join(t.TempDir(), "quiet.db"), OneOffDebug(false), OneOffPool(2, 4)))
+	if db.Logger != logger.Default {
+		t.Error("SQLiteWith(OneOffDebug(false)) logger is in debug mode")
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("DB() error: %v", err)
+	}
+	if got := sqlDB.Stats().MaxOpenConnections; got != 4 {
+		t.Errorf("MaxOpenConnections = %d, want 4", got)
+	}
+	if maxIdle, _, _ := poolSettings(sqlDB); maxIdle != 2 {
+		t.Errorf("max idle conns = %d, want 2", maxIdle)
+	}
+}
+
+func TestAutoMigrate(t *testing.T) {
+	type widget struct {
+		Name string
+		ID   uint `gorm:"primaryKey"`
+	}
+
+	db := closeOnCleanup(t, OneOffDB.SQLite(filepath.Join(t.TempDir(), "test.db")))
+
+	if err := AutoMigrate(db, &widget{}); err != nil {
+		t.Fatalf("AutoMigrate() error: %v", err)
+	}
+	if !db.Migrator().HasTable(&widget{}) {
+		t.Error("AutoMigrate() did not create the widgets table")
+	}
+}
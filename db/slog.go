@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SlowQueryThreshold is the default duration above which NewSlogLogger reports a statement as slow
+const SlowQueryThreshold = 200 * time.Millisecond
+
+// slogLogger routes gorm logs to a slog.Logger
+type slogLogger struct {
+	l             *slog.Logger
+	level         logger.LogLevel
+	slowThreshold time.Duration
+}
+
+// SlogOption overrides a setting of NewSlogLogger
+type SlogOption func(*slogLogger)
+
+// SlogSlowThreshold reports statements slower than d as slow instead of SlowQueryThreshold,
+// like logger.Config.SlowThreshold. A zero or negative d stops reporting slow statements.
+func SlogSlowThreshold(d time.Duration) SlogOption {
+	return func(s *slogLogger) {
+		s.slowThreshold = d
+	}
+}
+
+// NewSlogLogger returns a gorm logger writing to l, to be passed to NewDB. Depending on level:
+//   - logger.Error: failed statements at slog error level
+//   - logger.Warn: plus statements slower than SlowQueryThreshold, see SlogSlowThreshold, at warn level
+//   - logger.Info: plus every statement at debug level
+//
+// Statement records carry sql, duration and rows attributes. gorm.ErrRecordNotFound isn't
+// logged, as not finding a record is an expected outcome of Get.
+func NewSlogLogger(l *slog.Logger, level logger.LogLevel, opts ...SlogOption) logger.Interface {
+	s := &slogLogger{l: l, level: level, slowThreshold: SlowQueryThreshold}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *slogLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &slogLogger{l: s.l, level: level, slowThreshold: s.slowThreshold}
+}
+
+func (s *slogLogger) Info(ctx context.Context, msg string, args ...any) {
+	if s.level >= logger.Info {
+		s.l.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (s *slogLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if s.level >= logger.Warn {
+		s.l.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (s *slogLogger) Error(ctx context.Context, msg string, args ...any) {
+	if s.level >= logger.Error {
+		s.l.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (s *slogLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if s.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	var (
+		level slog.Level
+		msg   string
+	)
+	switch {
+	case err != nil && s.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "query failed"
+	case s.slowThreshold > 0 && elapsed > s.slowThreshold && s.level >= logger.Warn:
+		level, msg = slog.LevelWarn, "slow query"
+	case s.level >= logger.Info:
+		level, msg = slog.LevelDebug, "query"
+	default:
+		return
+	}
+
+	if !s.l.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Duration("duration", elapsed),
+		slog.Int64("rows", rows),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	s.l.LogAttrs(ctx, level, msg, attrs...)
+}
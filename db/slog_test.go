@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordingHandler keeps the records logged through it
+type recordingHandler struct {
+	records []slog.Record
+	mu      sync.Mutex
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordingHandler) WithGroup(string) slog.Handler { return h }
+
+func recordAttrs(r slog.Record) map[string]slog.Value {
+	attrs := make(map[string]slog.Value)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	return attrs
+}
+
+func TestNewSlogLogger(t *testing.T) {
+	ctx := context.Background()
+	statement := func() (string, int64) { return "SELECT * FROM users", 3 }
+	slow := time.Now().Add(-2 * SlowQueryThreshold)
+
+	tests := []struct {
+		name      string
+		begin     time.Time
+		err       error
+		level     logger.LogLevel
+		wantLevel slog.Level
+		wantLog   bool
+	}{
+		{name: "slow query", level: logger.Warn, begin: slow, wantLog: true, wantLevel: slog.LevelWarn},
+		{name: "fast query at warn", level: logger.Warn, begin: time.Now()},
+		{name: "fast query at info", level: logger.Info, begin: time.Now(), wantLog: true, wantLevel: slog.LevelDebug},
+		{name: "error", level: logger.Error, begin: slow, err: errors.New("boom"), wantLog: true, wantLevel: slog.LevelError},
+		{name: "slow query at error", level: logger.Error, begin: slow},
+		{name: "record not found", level: logger.Warn, begin: time.Now(), err: gorm.ErrRecordNotFound},
+		{name: "silent", level: logger.Silent, begin: slow, err: errors.New("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &recordingHandler{}
+			NewSlogLogger(slog.New(h), tt.level).Trace(ctx, tt.begin, statement, tt.err)
+
+			if !tt.wantLog {
+				if len(h.records) != 0 {
+					t.Errorf("logged %d records, want none", len(h.records))
+				}
+				return
+			}
+			if len(h.records) != 1 {
+				t.Fatalf("logged %d records, want 1", len(h.records))
+			}
+
+			r := h.records[0]
+			if r.Level != tt.wantLevel {
+				t.Errorf("level = %v, want %v", r.Level, tt.wantLevel)
+			}
+			attrs := recordAttrs(r)
+			if got := attrs["sql"].String(); got != "SELECT * FROM users" {
+				t.Errorf("sql attribute = %q, want the statement", got)
+			}
+			if got := attrs["rows"].Int64(); got != 3 {
+				t.Errorf("rows attribute = %d, want 3", got)
+			}
+			if _, ok := attrs["duration"]; !ok {
+				t.Error("no duration attribute")
+			}
+		})
+	}
+}
+
+func TestNewSlogLogger_logMode(t *testing.T) {
+	h := &recordingHandler{}
+	l := NewSlogLogger(slog.New(h), logger.Silent).LogMode(logger.Info)
+
+	l.Info(context.Background(), "connected to %s", "sqlite")
+	if len(h.records) != 1 || h.records[0].Message != "connected to sqlite" {
+		t.Errorf("records = %v, want the formatted info message", h.records)
+	}
+}
+
+func TestNewSlogLogger_slowThreshold(t *testing.T) {
+	ctx := context.Background()
+	statement := func() (string, int64) { return "SELECT * FROM users", 3 }
+	h := &recordingHandler{}
+	// LogMode must keep the threshold, as gorm calls it when sessions change the level
+	l := NewSlogLogger(slog.New(h), logger.Error, SlogSlowThreshold(10*time.Millisecond)).LogMode(logger.Warn)
+
+	l.Trace(ctx, time.Now().Add(-50*time.Millisecond), statement, nil)
+	if len(h.records) != 1 || h.records[0].Level != slog.LevelWarn || h.records[0].Message != "slow query" {
+		t.Fatalf("records = %v, want one slow query warning", h.records)
+	}
+
+	l.Trace(ctx, time.Now(), statement, nil)
+	if len(h.records) != 1 {
+		t.Errorf("logged %d records, want the fast query skipped", len(h.records))
+	}
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Option customizes the DB built by NewDB
+type Option func(*options)
+
+type options struct {
+	tracer Tracer
+}
+
+// WithTracer traces every statement of the DB with t, see UseTracer
+func WithTracer(t Tracer) Option {
+	return func(o *options) {
+		o.tracer = t
+	}
+}
+
+// Tracer receives a span per database statement. It is deliberately tiny so that
+// an OpenTelemetry tracer can be adapted without this package depending on otel:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) StartSpan(ctx context.Context, op string) (context.Context, db.EndSpan) {
+//		ctx, span := t.Start(ctx, "gorm."+op, trace.WithSpanKind(trace.SpanKindClient))
+//		return ctx, func(stmt string, rows int64, err error) {
+//			span.SetAttributes(semconv.DBQueryText(stmt), attribute.Int64("db.rows_affected", rows))
+//			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+//				span.RecordError(err)
+//				span.SetStatus(codes.Error, err.Error())
+//			}
+//			span.End()
+//		}
+//	}
+type Tracer interface {
+	// StartSpan is called before a statement of the given operation (create, query, update,
+	// delete, row or raw) runs. The returned context is used to run the statement.
+	StartSpan(ctx context.Context, operation string) (context.Context, EndSpan)
+}
+
+// EndSpan is called once the statement has run, with its SQL, affected rows and error
+type EndSpan func(statement string, rowsAffected int64, err error)
+
+const endSpanKey = "dry:end_span"
+
+// UseTracer registers gorm callbacks reporting every statement run through db to t.
+// A DB without a tracer has no extra callbacks.
+func UseTracer(db *gorm.DB, t Tracer) error {
+	type registrar interface {
+		Register(name string, fn func(*gorm.DB)) error
+	}
+
+	cb := db.Callback()
+	ops := []struct {
+		name          string
+		before, after registrar
+	}{
+		{"create", cb.Create().Before("*"), cb.Create().After("*")},
+		{"query", cb.Query().Before("*"), cb.Query().After("*")},
+		{"update", cb.Update().Before("*"), cb.Update().After("*")},
+		{"delete", cb.Delete().Before("*"), cb.Delete().After("*")},
+		{"row", cb.Row().Before("*"), cb.Row().After("*")},
+		{"raw", cb.Raw().Before("*"), cb.Raw().After("*")},
+	}
+
+	for _, op := range ops {
+		if err := op.before.Register("dry:trace_before_"+op.name, startSpan(t, op.name)); err != nil {
+			return fmt.Errorf("db: register tracing callback: %w", err)
+		}
+		if err := op.after.Register("dry:trace_after_"+op.name, endSpan); err != nil {
+			return fmt.Errorf("db: register tracing callback: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func startSpan(t Tracer, operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx, end := t.StartSpan(tx.Statement.Context, operation)
+		tx.Statement.Context = ctx
+		tx.InstanceSet(endSpanKey, end)
+	}
+}
+
+func endSpan(tx *gorm.DB) {
+	v, ok := tx.InstanceGet(endSpanKey)
+	if !ok {
+		return
+	}
+	if end, ok := v.(EndSpan); ok {
+		end(tx.Statement.SQL.String(), tx.RowsAffected, tx.Error)
+	}
+}
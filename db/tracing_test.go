@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/downtoyonder/dry-go/config"
+	"gorm.io/gorm/logger"
+)
+
+type recordedSpan struct {
+	err       error
+	operation string
+	statement string
+	duration  time.Duration
+	rows      int64
+}
+
+type recordingTracer struct {
+	spans []recordedSpan
+	mu    sync.Mutex
+}
+
+func (r *recordingTracer) StartSpan(ctx context.Context, operation string) (context.Context, EndSpan) {
+	start := time.Now()
+	return ctx, func(statement string, rows int64, err error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.spans = append(r.spans, recordedSpan{operation: operation, statement: statement, rows: rows, err: err, duration: time.Since(start)})
+	}
+}
+
+func TestWithTracer(t *testing.T) {
+	c := config.NewViperFromMap(map[string]any{
+		"driver": "sqlite",
+		"dsn":    filepath.Join(t.TempDir(), "test.db"),
+	})
+	tracer := &recordingTracer{}
+
+	db := NewDB(c, logger.Discard, WithTracer(tracer))
+
+	type widget struct {
+		Name string
+		ID   uint `gorm:"primaryKey"`
+	}
+	if err := AutoMigrate(db, &widget{}); err != nil {
+		t.Fatalf("AutoMigrate() error: %v", err)
+	}
+	if err := db.Create(&widget{Name: "a"}).Error; err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	tracer.spans = nil
+	var got []widget
+	if err := db.Where("name = ?", "a").Find(&got).Error; err != nil {
+		t.Fatalf("Find() error: %v", err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1: %+v", len(tracer.spans), tracer.spans)
+	}
+	span := tracer.spans[0]
+	if span.operation != "query" || !strings.Contains(span.statement, "SELECT") || span.rows != 1 || span.err != nil || span.duration <= 0 {
+		t.Errorf("span = %+v, want a successful query span with its SQL and timing", span)
+	}
+}
+
+func TestNewDB_withoutTracer(t *testing.T) {
+	c := config.NewViperFromMap(map[string]any{
+		"driver": "sqlite",
+		"dsn":    filepath.Join(t.TempDir(), "test.db"),
+	})
+
+	db := NewDB(c, logger.Discard)
+
+	if db.Callback().Query().Get("dry:trace_before_query") != nil {
+		t.Error("tracing callbacks registered without a tracer")
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Keys returns the keys of m. The order is arbitrary, like map iteration; use SortedKeys
+// for a stable order.
+//
+// Example:
+//
+//	byName := FieldMapStructFn(users, SelectAll(func(u User) string { return u.Name }))
+//	names := Keys(byName)
+func Keys[K comparable, V any](m map[K]V) []K {
+	result := make([]K, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
+
+// SortedKeys is like Keys but returns the keys in ascending order.
+func SortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	result := Keys(m)
+	slices.Sort(result)
+	return result
+}
+
+// Values returns the values of m. The order is arbitrary, like map iteration.
+//
+// Example:
+//
+//	byName := FieldMapStructFn(users, SelectAll(func(u User) string { return u.Name }))
+//	uniqueUsers := Values(byName)
+func Values[K comparable, V any](m map[K]V) []V {
+	result := make([]V, 0, len(m))
+	for _, v := range m {
+		result = append(result, v)
+	}
+	return result
+}
+
+// MapValues returns a map with the keys of m and the values transformed by fn.
+//
+// Example:
+//
+//	byID := FieldMapStructFn(users, SelectAll(func(u User) int { return u.ID }))
+//	dtoByID := MapValues(byID, toUserDTO)
+func MapValues[K comparable, V, W any](m map[K]V, fn func(V) W) map[K]W {
+	result := make(map[K]W, len(m))
+	for k, v := range m {
+		result[k] = fn(v)
+	}
+	return result
+}
+
+// MapKeys returns a map with the keys of m transformed by fn and the same values.
+// When fn maps several keys to the same one, the last entry in map iteration order wins,
+// so which of the colliding values is kept is arbitrary.
+//
+// Example:
+//
+//	byLowerName := MapKeys(byName, strings.ToLower)
+func MapKeys[K comparable, V any, K2 comparable](m map[K]V, fn func(K) K2) map[K2]V {
+	result := make(map[K2]V, len(m))
+	for k, v := range m {
+		result[fn(k)] = v
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// OrderedMap is a map that remembers the order in which keys were first set.
+// The zero value is an empty map ready to use. It is not safe for concurrent use.
+type OrderedMap[K comparable, V any] struct {
+	values map[K]V
+	keys   []K
+}
+
+func NewOrderedMap[K comparable, V any]() *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{values: make(map[K]V)}
+}
+
+// Set sets the value of key. Setting an existing key keeps its position.
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	if m.values == nil {
+		m.values = make(map[K]V)
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// Delete removes key, taking time proportional to the number of keys.
+func (m *OrderedMap[K, V]) Delete(key K) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	m.keys = slices.DeleteFunc(m.keys, func(k K) bool { return k == key })
+}
+
+func (m *OrderedMap[K, V]) Len() int {
+	return len(m.keys)
+}
+
+// Keys returns the keys in insertion order.
+func (m *OrderedMap[K, V]) Keys() []K {
+	return slices.Clone(m.keys)
+}
+
+// Range calls fn for each key and value in insertion order until fn returns false.
+func (m *OrderedMap[K, V]) Range(fn func(key K, value V) bool) {
+	for _, key := range m.keys {
+		if !fn(key, m.values[key]) {
+			return
+		}
+	}
+}
+
+// MarshalJSON encodes m as a JSON object with the keys in insertion order.
+// Keys are encoded like the keys of a plain map: strings as is, encoding.TextMarshaler
+// with MarshalText and other types with fmt.Sprint. The value receiver lets OrderedMaps held by
+// value, e.g. in struct fields, and not only pointers marshal their entries.
+func (m OrderedMap[K, V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := jsonKey(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func jsonKey(key any) ([]byte, error) {
+	switch k := key.(type) {
+	case string:
+		return json.Marshal(k)
+	case encoding.TextMarshaler:
+		text, err := k.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(string(text))
+	default:
+		return json.Marshal(fmt.Sprint(k))
+	}
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestOrderedMap(t *testing.T) {
+	var m OrderedMap[string, int]
+	for i, key := range []string{"c", "a", "b", "d"} {
+		m.Set(key, i)
+	}
+	m.Set("a", 10) // keeps its position
+	m.Delete("b")
+	m.Delete("missing")
+	m.Set("b", 20) // re-added at the end
+
+	wantKeys := []string{"c", "a", "d", "b"}
+	if got := m.Keys(); !slices.Equal(got, wantKeys) {
+		t.Errorf("Keys() = %v, want %v", got, wantKeys)
+	}
+	if m.Len() != len(wantKeys) {
+		t.Errorf("Len() = %d, want %d", m.Len(), len(wantKeys))
+	}
+	if v, ok := m.Get("a"); !ok || v != 10 {
+		t.Errorf("Get(a) = %d, %v, want 10, true", v, ok)
+	}
+	if _, ok := m.Get("missing"); ok {
+		t.Error("Get(missing) reported a value")
+	}
+
+	var ranged []string
+	m.Range(func(key string, _ int) bool {
+		ranged = append(ranged, key)
+		return key != "d"
+	})
+	if want := wantKeys[:3]; !slices.Equal(ranged, want) {
+		t.Errorf("Range() visited %v, want %v", ranged, want)
+	}
+
+	data, err := json.Marshal(&m)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	if want := `{"c":0,"a":10,"d":3,"b":20}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+}
+
+func TestOrderedMap_intKeysJSON(t *testing.T) {
+	m := NewOrderedMap[int, []string]()
+	m.Set(2, []string{"b"})
+	m.Set(1, nil)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	if want := `{"2":["b"],"1":null}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+}
+
+func TestOrderedMap_byValueJSON(t *testing.T) {
+	type response struct {
+		Counts OrderedMap[string, int] `json:"counts"`
+	}
+
+	var r response
+	r.Counts.Set("b", 1)
+	r.Counts.Set("a", 2)
+
+	// Neither the struct passed by value nor its field is addressable
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	if want := `{"counts":{"b":1,"a":2}}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var empty OrderedMap[string, int]
+	if data, err := json.Marshal(any(empty)); err != nil || string(data) != "{}" {
+		t.Errorf("Marshal(zero value) = %s, %v, want {}", data, err)
+	}
+}
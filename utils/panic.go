@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"runtime/debug"
+	"sync/atomic"
 )
 
 func PanicErr(err error) {
@@ -11,9 +13,61 @@ func PanicErr(err error) {
 	}
 }
 
+// Must returns v, or panics if err is not nil like PanicErr, e.g. Must(strconv.Atoi("42")).
+func Must[T any](v T, err error) T {
+	PanicErr(err)
+
+	return v
+}
+
+// RecoverHandler receives a recovered panic value and the stack of the panicking goroutine.
+type RecoverHandler func(recovered any, stack []byte)
+
+var recoverHandler atomic.Pointer[RecoverHandler]
+
+func printRecovered(recovered any, stack []byte) {
+	fmt.Printf("panic: %v\n%s", recovered, string(stack))
+}
+
+// SetRecoverHandler replaces the handler used by RecoverWithStack and SafeGo, which prints to
+// stdout by default, e.g. to log panics with slog. A nil handler restores the default.
+func SetRecoverHandler(handle RecoverHandler) {
+	if handle == nil {
+		recoverHandler.Store(nil)
+		return
+	}
+	recoverHandler.Store(&handle)
+}
+
+// RecoverWithStack recovers a panic and reports it to the handler set by SetRecoverHandler.
+// It must be deferred directly: defer RecoverWithStack().
 func RecoverWithStack() {
 	if r := recover(); r != nil {
-		stack := debug.Stack()
-		fmt.Printf("panic: %v\n%s", r, string(stack))
+		handle := printRecovered
+		if h := recoverHandler.Load(); h != nil {
+			handle = *h
+		}
+		handle(r, debug.Stack())
+	}
+}
+
+// RecoverWithHandler is like RecoverWithStack but reports to handle: defer RecoverWithHandler(handle).
+func RecoverWithHandler(handle func(recovered any, stack []byte)) {
+	if r := recover(); r != nil {
+		handle(r, debug.Stack())
 	}
 }
+
+// SafeGo runs fn in a new goroutine, recovering any panic with RecoverWithStack instead of
+// crashing the process.
+func SafeGo(fn func()) {
+	go func() {
+		defer RecoverWithStack()
+		fn()
+	}()
+}
+
+// SafeGoCtx is like SafeGo for functions taking a context.
+func SafeGoCtx(ctx context.Context, fn func(context.Context)) {
+	SafeGo(func() { fn(ctx) })
+}
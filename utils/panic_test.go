@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestMust(t *testing.T) {
+	if got := Must(strconv.Atoi("42")); got != 42 {
+		t.Errorf("Must() = %d, want 42", got)
+	}
+
+	errBoom := errors.New("boom")
+	defer func() {
+		if r := recover(); r != errBoom {
+			t.Errorf("Must() panicked with %v, want %v", r, errBoom)
+		}
+	}()
+	Must(0, errBoom)
+	t.Error("Must() did not panic on error")
+}
+
+func TestSafeGo(t *testing.T) {
+	done := make(chan struct{})
+	SafeGo(func() {
+		defer close(done)
+		panic("boom")
+	})
+	<-done
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	got := make(chan any, 1)
+	SafeGoCtx(ctx, func(ctx context.Context) {
+		got <- ctx.Value(ctxKey{})
+		panic("boom")
+	})
+	if v := <-got; v != "value" {
+		t.Errorf("SafeGoCtx() passed ctx value %v, want %q", v, "value")
+	}
+}
+
+func TestRecoverHandler(t *testing.T) {
+	var (
+		gotRecovered any
+		gotStack     []byte
+	)
+	record := func(recovered any, stack []byte) {
+		gotRecovered, gotStack = recovered, stack
+	}
+
+	t.Run("SetRecoverHandler", func(t *testing.T) {
+		gotRecovered, gotStack = nil, nil
+		SetRecoverHandler(record)
+		defer SetRecoverHandler(nil)
+
+		func() {
+			defer RecoverWithStack()
+			panic("boom")
+		}()
+
+		if gotRecovered != "boom" {
+			t.Errorf("handler recovered %v, want %q", gotRecovered, "boom")
+		}
+		if len(gotStack) == 0 {
+			t.Error("handler received an empty stack")
+		}
+	})
+
+	t.Run("RecoverWithHandler", func(t *testing.T) {
+		gotRecovered, gotStack = nil, nil
+
+		func() {
+			defer RecoverWithHandler(record)
+			panic("bang")
+		}()
+
+		if gotRecovered != "bang" {
+			t.Errorf("handler recovered %v, want %q", gotRecovered, "bang")
+		}
+		if len(gotStack) == 0 {
+			t.Error("handler received an empty stack")
+		}
+	})
+}
@@ -0,0 +1,22 @@
+package utils
+
+// Ptr returns a pointer to a copy of v, e.g. to fill pointer fields from literals.
+//
+// Example:
+//
+//	user := User{Nickname: Ptr("bob")}
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Deref returns the value p points to, or fallback when p is nil.
+//
+// Example:
+//
+//	nickname := Deref(user.Nickname, "anonymous")
+func Deref[T any](p *T, fallback T) T {
+	if p == nil {
+		return fallback
+	}
+	return *p
+}
@@ -0,0 +1,306 @@
+package utils
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Map transforms each element of list with fn and returns the results in the same order.
+// An empty input yields an empty, non-nil slice.
+//
+// Example:
+//
+//	type User struct { ID int; Name string }
+//	users := []User{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Bob"}}
+//	labels := Map(users, func(u User) string { return fmt.Sprintf("%d:%s", u.ID, u.Name) })
+//	// labels: []string{"1:Alice", "2:Bob"}
+func Map[T, U any](list []T, fn func(T) U) []U {
+	result := make([]U, len(list))
+	for i, item := range list {
+		result[i] = fn(item)
+	}
+	return result
+}
+
+// Filter returns the elements of list for which pred returns true, preserving order.
+// An empty input yields an empty, non-nil slice.
+//
+// Example:
+//
+//	adults := Filter(users, func(u User) bool { return u.Age >= 18 })
+func Filter[T any](list []T, pred func(T) bool) []T {
+	result := make([]T, 0, len(list))
+	for _, item := range list {
+		if pred(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Reject is the complement of Filter: it returns the elements of list for which pred returns false,
+// preserving order.
+// An empty input yields an empty, non-nil slice.
+//
+// Example:
+//
+//	minors := Reject(users, func(u User) bool { return u.Age >= 18 })
+func Reject[T any](list []T, pred func(T) bool) []T {
+	return Filter(list, func(item T) bool { return !pred(item) })
+}
+
+// Find returns the first element of list for which pred returns true, or false if there is none.
+//
+// Example:
+//
+//	bob, ok := Find(users, func(u User) bool { return u.Name == "Bob" })
+func Find[T any](list []T, pred func(T) bool) (T, bool) {
+	if i := FindIndex(list, pred); i >= 0 {
+		return list[i], true
+	}
+	var zero T
+	return zero, false
+}
+
+// FindIndex returns the index of the first element of list for which pred returns true, or -1.
+func FindIndex[T any](list []T, pred func(T) bool) int {
+	for i, item := range list {
+		if pred(item) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Contains reports whether target is in list.
+func Contains[T comparable](list []T, target T) bool {
+	return IndexOf(list, target) >= 0
+}
+
+// IndexOf returns the index of the first occurrence of target in list, or -1.
+func IndexOf[T comparable](list []T, target T) int {
+	return slices.Index(list, target)
+}
+
+// Partition splits list in a single pass into the elements for which pred returns true and the
+// rest, both preserving order. Both results are non-nil.
+//
+// Example:
+//
+//	valid, invalid := Partition(users, func(u User) bool { return u.Name != "" })
+func Partition[T any](list []T, pred func(T) bool) (matched, rest []T) {
+	matched, rest = make([]T, 0, len(list)), make([]T, 0, len(list))
+	for _, item := range list {
+		if pred(item) {
+			matched = append(matched, item)
+		} else {
+			rest = append(rest, item)
+		}
+	}
+	return matched, rest
+}
+
+// Reduce folds list into a single value, starting from initial and applying fn to the
+// accumulator and each element in order. An empty input returns initial unchanged.
+//
+// Example:
+//
+//	total := Reduce(users, 0, func(acc int, u User) int { return acc + u.Age })
+func Reduce[T, Acc any](list []T, initial Acc, fn func(Acc, T) Acc) Acc {
+	acc := initial
+	for _, item := range list {
+		acc = fn(acc, item)
+	}
+	return acc
+}
+
+// Flatten concatenates lists in order into a single slice, e.g. to join the pages of a List.
+// Nil inner slices are skipped. An empty input yields an empty, non-nil slice.
+//
+// Example:
+//
+//	all := Flatten([][]int{{1, 2}, nil, {3}})
+//	// all: []int{1, 2, 3}
+func Flatten[T any](lists [][]T) []T {
+	size := 0
+	for _, list := range lists {
+		size += len(list)
+	}
+
+	result := make([]T, 0, size)
+	for _, list := range lists {
+		result = append(result, list...)
+	}
+	return result
+}
+
+// FlatMap applies fn to each element of list and concatenates the results in order,
+// an element mapped to nil or an empty slice contributing nothing.
+//
+// Example:
+//
+//	roles := FlatMap(users, func(u User) []string { return u.Roles })
+func FlatMap[T, U any](list []T, fn func(T) []U) []U {
+	return Flatten(Map(list, fn))
+}
+
+// Reverse returns a reversed copy of list and leaves list unchanged.
+// An empty input yields an empty, non-nil slice.
+func Reverse[T any](list []T) []T {
+	result := make([]T, len(list))
+	for i, item := range list {
+		result[len(list)-1-i] = item
+	}
+	return result
+}
+
+// ReverseInPlace reverses list itself, mutating it; use Reverse to keep the input.
+func ReverseInPlace[T any](list []T) {
+	slices.Reverse(list)
+}
+
+// Number is the set of integer and floating-point types that SumBy can add up.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// MaxBy returns the element of list with the largest value selected by sel, or false if list is empty.
+// On ties the first such element wins.
+//
+// Example:
+//
+//	oldest, ok := MaxBy(users, func(u User) int { return u.Age })
+func MaxBy[T any, V cmp.Ordered](list []T, sel func(T) V) (T, bool) {
+	return extremeBy(list, sel, func(v, best V) bool { return v > best })
+}
+
+// MinBy returns the element of list with the smallest value selected by sel, or false if list is empty.
+// On ties the first such element wins.
+//
+// Example:
+//
+//	youngest, ok := MinBy(users, func(u User) int { return u.Age })
+func MinBy[T any, V cmp.Ordered](list []T, sel func(T) V) (T, bool) {
+	return extremeBy(list, sel, func(v, best V) bool { return v < best })
+}
+
+func extremeBy[T any, V cmp.Ordered](list []T, sel func(T) V, better func(v, best V) bool) (T, bool) {
+	if len(list) == 0 {
+		var zero T
+		return zero, false
+	}
+
+	best, bestValue := list[0], sel(list[0])
+	for _, item := range list[1:] {
+		if v := sel(item); better(v, bestValue) {
+			best, bestValue = item, v
+		}
+	}
+	return best, true
+}
+
+// SumBy adds up the values selected by sel. An empty input sums to zero.
+//
+// Example:
+//
+//	totalAge := SumBy(users, func(u User) int { return u.Age })
+func SumBy[T any, V Number](list []T, sel func(T) V) V {
+	var sum V
+	for _, item := range list {
+		sum += sel(item)
+	}
+	return sum
+}
+
+// Pair holds two values, e.g. the elements at the same index of two slices zipped by Zip.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Zip pairs the elements of as and bs at the same index. When the slices differ in length
+// the extra elements of the longer one are dropped, so the result has the shorter length.
+//
+// Example:
+//
+//	ids := PluckFn(users, SelectAll(func(u User) int { return u.ID }))
+//	names := PluckFn(users, SelectAll(func(u User) string { return u.Name }))
+//	pairs := Zip(ids, names) // pairs[0]: {1 Alice}
+func Zip[A, B any](as []A, bs []B) []Pair[A, B] {
+	result := make([]Pair[A, B], min(len(as), len(bs)))
+	for i := range result {
+		result[i] = Pair[A, B]{First: as[i], Second: bs[i]}
+	}
+	return result
+}
+
+// Unzip splits pairs back into the slice of first values and the slice of second values.
+func Unzip[A, B any](pairs []Pair[A, B]) ([]A, []B) {
+	as, bs := make([]A, len(pairs)), make([]B, len(pairs))
+	for i, p := range pairs {
+		as[i], bs[i] = p.First, p.Second
+	}
+	return as, bs
+}
+
+// Take returns a copy of the first n elements of list, n being clamped to [0, len(list)].
+func Take[T any](list []T, n int) []T {
+	n = max(0, min(n, len(list)))
+	return append(make([]T, 0, n), list[:n]...)
+}
+
+// Drop returns a copy of list without its first n elements, n being clamped to [0, len(list)].
+func Drop[T any](list []T, n int) []T {
+	n = max(0, min(n, len(list)))
+	return append(make([]T, 0, len(list)-n), list[n:]...)
+}
+
+// TakeWhile returns a copy of the leading elements of list for which pred returns true.
+//
+// Example:
+//
+//	sorted := []int{1, 3, 5, 8, 9}
+//	odd := TakeWhile(sorted, func(n int) bool { return n%2 == 1 }) // []int{1, 3, 5}
+func TakeWhile[T any](list []T, pred func(T) bool) []T {
+	return Take(list, leadingCount(list, pred))
+}
+
+// DropWhile returns a copy of list without the leading elements for which pred returns true.
+func DropWhile[T any](list []T, pred func(T) bool) []T {
+	return Drop(list, leadingCount(list, pred))
+}
+
+// leadingCount returns how many elements at the start of list satisfy pred
+func leadingCount[T any](list []T, pred func(T) bool) int {
+	for i, item := range list {
+		if !pred(item) {
+			return i
+		}
+	}
+	return len(list)
+}
+
+// Window returns every contiguous run of size elements of list, len(list)-size+1 windows in
+// order, or none when size exceeds len(list). The windows share the memory of list, so copy
+// one before modifying it. Like slices.Chunk, it panics if size is not positive.
+//
+// Example:
+//
+//	Window([]int{1, 2, 3, 4}, 2) // [][]int{{1, 2}, {2, 3}, {3, 4}}
+func Window[T any](list []T, size int) [][]T {
+	if size < 1 {
+		panic("cannot be less than 1")
+	}
+	if size > len(list) {
+		return [][]T{}
+	}
+
+	result := make([][]T, 0, len(list)-size+1)
+	for i := 0; i+size <= len(list); i++ {
+		// Capping the capacity keeps an append to one window from overwriting the next
+		result = append(result, list[i:i+size:i+size])
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package utils
+
+import "sync"
+
+// SyncSet is a Set that is safe for concurrent use by multiple goroutines.
+// Reads take a read lock and writes take an exclusive lock.
+// The zero value is an empty set ready to use.
+type SyncSet[T comparable] struct {
+	s  Set[T]
+	mu sync.RWMutex
+}
+
+func NewSyncSet[T comparable](items ...T) *SyncSet[T] {
+	return &SyncSet[T]{s: NewSet(items...)}
+}
+
+func (s *SyncSet[T]) Add(item T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.s == nil {
+		s.s = NewSet[T]()
+	}
+	s.s.Add(item)
+}
+
+func (s *SyncSet[T]) Remove(item T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.s.Remove(item)
+}
+
+func (s *SyncSet[T]) Contain(item T) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.s.Contain(item)
+}
+
+func (s *SyncSet[T]) ToSlice() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.s.ToSlice()
+}
+
+// Len returns the number of elements in the set.
+func (s *SyncSet[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.s)
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"sync"
+	"testing"
+)
+
+// Run with -race to verify concurrent access is safe.
+func TestSyncSet_concurrent(t *testing.T) {
+	const (
+		workers = 8
+		perWork = 100
+	)
+
+	set := NewSyncSet[int]()
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWork {
+				id := w*perWork + i
+				set.Add(id)
+				set.Add(id) // duplicate adds are no-ops
+				_ = set.Contain(id)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := set.Len(); got != workers*perWork {
+		t.Errorf("Len() = %d, want %d", got, workers*perWork)
+	}
+	if got := len(set.ToSlice()); got != workers*perWork {
+		t.Errorf("len(ToSlice()) = %d, want %d", got, workers*perWork)
+	}
+
+	set.Remove(0)
+	if set.Contain(0) {
+		t.Error("Contain(0) = true after Remove(0)")
+	}
+}
+
+func TestSyncSet_zeroValue(t *testing.T) {
+	var set SyncSet[string]
+	if set.Contain("a") || set.Len() != 0 {
+		t.Fatal("zero value SyncSet is not empty")
+	}
+
+	set.Add("a")
+	if !set.Contain("a") || set.Len() != 1 {
+		t.Errorf("after Add, Contain(a) = %v and Len() = %d; want true and 1", set.Contain("a"), set.Len())
+	}
+}
@@ -1,7 +1,11 @@
 package utils
 
 import (
-	mapset "github.com/deckarep/golang-set/v2"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
 )
 
 type SelectFn[StructT any, FieldT any] func(StructT) (FieldT, bool)
@@ -12,9 +16,49 @@ func SelectAll[FieldT, StructT any](f func(structure StructT) FieldT) SelectFn[S
 	}
 }
 
+// SelectIf builds a selector that includes extract's value only for the elements matching pred.
+//
+// Example:
+//
+//	adultNames := PluckFn(users, SelectIf(func(u User) string { return u.Name }, func(u User) bool { return u.Age >= 18 }))
+func SelectIf[T, F any](extract func(T) F, pred func(T) bool) SelectFn[T, F] {
+	return func(structure T) (F, bool) {
+		if !pred(structure) {
+			var zero F
+			return zero, false
+		}
+		return extract(structure), true
+	}
+}
+
+// SelectMap transforms the values included by sel with fn, elements skipped by sel stay skipped.
+//
+// Example:
+//
+//	upperNames := PluckFn(users, SelectMap(SelectAll(func(u User) string { return u.Name }), strings.ToUpper))
+func SelectMap[T, A, B any](sel SelectFn[T, A], fn func(A) B) SelectFn[T, B] {
+	return func(structure T) (B, bool) {
+		value, ok := sel(structure)
+		if !ok {
+			var zero B
+			return zero, false
+		}
+		return fn(value), true
+	}
+}
+
+// SelectNone builds a selector that skips every element
+func SelectNone[T, F any]() SelectFn[T, F] {
+	return func(T) (F, bool) {
+		var zero F
+		return zero, false
+	}
+}
+
 // PluckFn extracts values using a field selector function.
 // This provides compile-time safety - if the field changes, the code won't compile.
 // This is the RECOMMENDED approach for extracting fields from structs.
+// Elements for which the selector returns false are skipped and produce no entry.
 //
 // Example:
 //
@@ -23,22 +67,61 @@ func SelectAll[FieldT, StructT any](f func(structure StructT) FieldT) SelectFn[S
 //	names := PluckFn(users, func(u User) string { return u.Name })
 //	ids := PluckFn(users, func(u User) int { return u.ID })
 func PluckFn[StructT any, FieldT any](list []StructT, fn SelectFn[StructT, FieldT]) []FieldT {
-	result := make([]FieldT, len(list))
-	for i, item := range list {
+	result := make([]FieldT, 0, len(list))
+	for _, item := range list {
 		field, add := fn(item)
 		if !add {
 			continue
 		}
-		result[i] = field
+		result = append(result, field)
 	}
 	return result
 }
 
+// PluckFnParallel is like PluckFn but runs fn on up to workers goroutines, for selectors doing
+// expensive work like parsing or hashing. The output keeps the order of list and honours the skip flag.
+// fn must be safe for concurrent use. With workers <= 1 it is the same as PluckFn.
+func PluckFnParallel[StructT any, FieldT any](list []StructT, fn SelectFn[StructT, FieldT], workers int) []FieldT {
+	if workers <= 1 || len(list) <= 1 {
+		return PluckFn(list, fn)
+	}
+	workers = min(workers, len(list))
+
+	var (
+		fields = make([]FieldT, len(list))
+		adds   = make([]bool, len(list))
+		chunk  = (len(list) + workers - 1) / workers
+		wg     sync.WaitGroup
+	)
+	// Each worker owns a contiguous range of indexes, so no two goroutines write the same element
+	for start := 0; start < len(list); start += chunk {
+		end := min(start+chunk, len(list))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				fields[i], adds[i] = fn(list[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	result := make([]FieldT, 0, len(list))
+	for i, field := range fields {
+		if adds[i] {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+// PluckUniqFn is like PluckFn but drops duplicate values, keeping the first-seen order like Uniq.
 func PluckUniqFn[StructT any, FieldT comparable](list []StructT, fn SelectFn[StructT, FieldT]) []FieldT {
 	return Uniq(PluckFn(list, fn))
 }
 
 // FieldStructMapFn creates a map from a slice of structs, using the value of a specified field as the key.
+// To map to something else than the elements themselves, prefer PluckMap.
 func FieldMapStructFn[FieldT comparable, StructT any](list []StructT, fn SelectFn[StructT, FieldT]) map[FieldT]StructT {
 	result := make(map[FieldT]StructT)
 	for _, item := range list {
@@ -51,6 +134,26 @@ func FieldMapStructFn[FieldT comparable, StructT any](list []StructT, fn SelectF
 	return result
 }
 
+// FieldMapStructUniqFn is like FieldMapStructFn but returns an error instead of silently
+// overwriting when two elements map to the same key. The error names the conflicting key
+// and both elements.
+func FieldMapStructUniqFn[FieldT comparable, StructT any](list []StructT, fn SelectFn[StructT, FieldT]) (map[FieldT]StructT, error) {
+	result := make(map[FieldT]StructT)
+	for _, item := range list {
+		field, add := fn(item)
+		if !add {
+			continue
+		}
+		if existing, ok := result[field]; ok {
+			return nil, fmt.Errorf("duplicate key %v: %+v and %+v", field, existing, item)
+		}
+		result[field] = item
+	}
+	return result, nil
+}
+
+// FieldMapFieldFn builds a map from a slice with separate key and value selectors, skipping the
+// elements either one skips. PluckMap is preferred, selecting both in one pass with a single flag.
 func FieldMapFieldFn[KeyT comparable, ValueT, StructT any](slice []StructT, keySel SelectFn[StructT, KeyT], valueSel SelectFn[StructT, ValueT]) map[KeyT]ValueT {
 	result := make(map[KeyT]ValueT)
 	for _, item := range slice {
@@ -67,6 +170,78 @@ func FieldMapFieldFn[KeyT comparable, ValueT, StructT any](slice []StructT, keyS
 	return result
 }
 
+// PluckMap builds a map from list with sel computing both the key and the value of each element,
+// and is the preferred way to build maps from slices. Elements for which sel returns false are
+// skipped; on duplicate keys the last element wins.
+//
+// Example:
+//
+//	labelByID := PluckMap(users, func(u User) (int, string, bool) {
+//		return u.ID, fmt.Sprintf("%s (%d)", u.Name, u.Age), true
+//	})
+func PluckMap[T any, K comparable, V any](list []T, sel func(T) (K, V, bool)) map[K]V {
+	result := make(map[K]V, len(list))
+	for _, item := range list {
+		key, value, add := sel(item)
+		if !add {
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// Associate is PluckMap under its former name.
+func Associate[StructT any, KeyT comparable, ValueT any](list []StructT, fn func(StructT) (KeyT, ValueT, bool)) map[KeyT]ValueT {
+	return PluckMap(list, fn)
+}
+
+// GroupByFn groups the elements of a slice by the key returned from keySel.
+// Unlike FieldMapStructFn, elements sharing a key are all kept, in input order.
+// Elements for which keySel returns false are skipped.
+//
+// Example:
+//
+//	users := []User{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Bob"}, {ID: 3, Name: "Alice"}}
+//	byName := GroupByFn(users, SelectAll(func(u User) string { return u.Name }))
+//	// byName["Alice"]: [{1 Alice} {3 Alice}]
+func GroupByFn[KeyT comparable, StructT any](list []StructT, keySel SelectFn[StructT, KeyT]) map[KeyT][]StructT {
+	result := make(map[KeyT][]StructT)
+	for _, item := range list {
+		key, add := keySel(item)
+		if !add {
+			continue
+		}
+		result[key] = append(result[key], item)
+	}
+	return result
+}
+
+// FieldMapSliceFn is like GroupByFn but collects a value selected from each element instead of the
+// element itself, in input order. Elements skipped by either selector produce no entry.
+//
+// Example:
+//
+//	namesByAge := FieldMapSliceFn(users,
+//		SelectAll(func(u User) int { return u.Age }),
+//		SelectAll(func(u User) string { return u.Name }))
+//	// namesByAge[30]: ["Alice", "Charlie"]
+func FieldMapSliceFn[KeyT comparable, ValueT, StructT any](list []StructT, keySel SelectFn[StructT, KeyT], valueSel SelectFn[StructT, ValueT]) map[KeyT][]ValueT {
+	result := make(map[KeyT][]ValueT)
+	for _, item := range list {
+		key, addKey := keySel(item)
+		if !addKey {
+			continue
+		}
+		value, addValue := valueSel(item)
+		if !addValue {
+			continue
+		}
+		result[key] = append(result[key], value)
+	}
+	return result
+}
+
 // SetCmp compares two slices and returns the elements that are new, overlapped, and deleted.
 // It takes two slices of comparable elements: `current` and `target`.
 // Returns three slices:
@@ -83,25 +258,33 @@ func FieldMapFieldFn[KeyT comparable, ValueT, StructT any](slice []StructT, keyS
 //	// overlapped: [2 3]
 //	// deleted: [1]
 func SetCmp[E comparable](current, target []E) (added, overlapped, deleted []E) {
-	currentSet := mapset.NewSet(current...)
-	targetSet := mapset.NewSet(target...)
+	addedSet, overlappedSet, deletedSet := SetCmpSet(current, target)
+
+	return addedSet.ToSlice(), overlappedSet.ToSlice(), deletedSet.ToSlice()
+}
 
-	added = targetSet.Difference(currentSet).ToSlice()
-	deleted = currentSet.Difference(targetSet).ToSlice()
-	overlapped = currentSet.Intersect(targetSet).ToSlice()
+// SetCmpSet is like SetCmp but returns the three buckets as Sets, so callers can check
+// membership directly without converting or sorting slices.
+func SetCmpSet[E comparable](current, target []E) (added, overlapped, deleted Set[E]) {
+	currentSet := NewSet(current...)
+	targetSet := NewSet(target...)
+
+	added = targetSet.Difference(currentSet)
+	deleted = currentSet.Difference(targetSet)
+	overlapped = currentSet.Intersect(targetSet)
 
 	return added, overlapped, deleted
 }
 
 // Uniq returns a slice containing only the unique elements from the input slice 'list'.
-// The order of elements in the returned slice is not guaranteed.
+// Elements keep the order in which they were first seen.
 // Elements must be of a comparable type.
 //
 // Example:
 //
 //	names := []string{"Alice", "Bob", "Alice", "Eve"}
 //	uniqueNames := Uniq(names)
-//	// uniqueNames might be: []string{"Alice", "Bob", "Eve"}
+//	// uniqueNames is: []string{"Alice", "Bob", "Eve"}
 func Uniq[T comparable](list []T) []T {
 	m := make(map[T]struct{}, len(list))
 	result := make([]T, 0, len(list))
@@ -115,6 +298,76 @@ func Uniq[T comparable](list []T) []T {
 	return result
 }
 
+// SliceDifference returns the unique elements of a that are not in b, in the order of a.
+//
+// Example:
+//
+//	diff := SliceDifference([]int{3, 1, 2, 1}, []int{2})
+//	// diff: [3 1]
+func SliceDifference[T comparable](a, b []T) []T {
+	exclude := NewSet(b...)
+	return Filter(Uniq(a), func(item T) bool { return !exclude.Contain(item) })
+}
+
+// SliceIntersection returns the unique elements present in both a and b, in the order of a.
+//
+// Example:
+//
+//	inter := SliceIntersection([]int{3, 1, 2, 3}, []int{2, 3})
+//	// inter: [3 2]
+func SliceIntersection[T comparable](a, b []T) []T {
+	include := NewSet(b...)
+	return Filter(Uniq(a), include.Contain)
+}
+
+// SliceUnion returns the unique elements of a followed by those of b, in first-seen order.
+//
+// Example:
+//
+//	union := SliceUnion([]int{3, 1}, []int{1, 2, 3})
+//	// union: [3 1 2]
+func SliceUnion[T comparable](a, b []T) []T {
+	return Uniq(slices.Concat(a, b))
+}
+
+// Count tallies how many times each element occurs in list.
+//
+// Example:
+//
+//	tally := Count([]string{"a", "b", "a"})
+//	// tally: map[string]int{"a": 2, "b": 1}
+func Count[T comparable](list []T) map[T]int {
+	return CountBy(list, func(item T) T { return item })
+}
+
+// CountBy tallies the elements of list by the key selected by keySel.
+//
+// Example:
+//
+//	usersPerName := CountBy(users, func(u User) string { return u.Name })
+func CountBy[T any, K comparable](list []T, keySel func(T) K) map[K]int {
+	result := make(map[K]int)
+	for _, item := range list {
+		result[keySel(item)]++
+	}
+	return result
+}
+
+// Coalesce returns the first of values that isn't the zero value of T, or the zero value if all are.
+//
+// Example:
+//
+//	addr := Coalesce(os.Getenv("ADDR"), conf.GetString("addr"), ":8080")
+func Coalesce[T comparable](values ...T) T {
+	var zero T
+	for _, v := range values {
+		if v != zero {
+			return v
+		}
+	}
+	return zero
+}
+
 type Set[T comparable] map[T]struct{}
 
 func NewSet[T comparable](items ...T) Set[T] {
@@ -125,6 +378,12 @@ func NewSet[T comparable](items ...T) Set[T] {
 	return s
 }
 
+// ToSet collects the elements of list into a Set, dropping duplicates.
+// Set.ToSlice, or SortedToSlice for ordered types, converts it back.
+func ToSet[T comparable](list []T) Set[T] {
+	return NewSet(list...)
+}
+
 func (s Set[T]) Add(item T) {
 	s[item] = struct{}{}
 }
@@ -133,11 +392,27 @@ func (s Set[T]) Remove(item T) {
 	delete(s, item)
 }
 
+// AddAll adds every item to s, e.g. s.AddAll(ids...) to add a whole slice.
+func (s Set[T]) AddAll(items ...T) {
+	for _, item := range items {
+		s[item] = struct{}{}
+	}
+}
+
+// RemoveAll removes every item from s, ignoring the ones it doesn't contain.
+func (s Set[T]) RemoveAll(items ...T) {
+	for _, item := range items {
+		delete(s, item)
+	}
+}
+
 func (s Set[T]) Contain(item T) bool {
 	_, exists := s[item]
 	return exists
 }
 
+// ToSlice is the inverse of ToSet, returning the elements in arbitrary order.
+// Use SortedToSlice for a stable order.
 func (s Set[T]) ToSlice() []T {
 	result := make([]T, 0, len(s))
 	for item := range s {
@@ -145,3 +420,103 @@ func (s Set[T]) ToSlice() []T {
 	}
 	return result
 }
+
+// Clone returns an independent copy of s.
+func (s Set[T]) Clone() Set[T] {
+	result := make(Set[T], len(s))
+	for item := range s {
+		result[item] = struct{}{}
+	}
+	return result
+}
+
+// SortedToSlice is like Set.ToSlice but returns the elements in ascending order.
+// It is a function rather than a method because methods can't narrow T to ordered types.
+func SortedToSlice[T cmp.Ordered](s Set[T]) []T {
+	result := s.ToSlice()
+	slices.Sort(result)
+	return result
+}
+
+// Union returns a new set containing the elements of both s and other.
+func (s Set[T]) Union(other Set[T]) Set[T] {
+	result := make(Set[T], len(s)+len(other))
+	for item := range s {
+		result[item] = struct{}{}
+	}
+	for item := range other {
+		result[item] = struct{}{}
+	}
+	return result
+}
+
+// Intersect returns a new set containing the elements present in both s and other.
+func (s Set[T]) Intersect(other Set[T]) Set[T] {
+	small, large := s, other
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+
+	result := make(Set[T])
+	for item := range small {
+		if large.Contain(item) {
+			result[item] = struct{}{}
+		}
+	}
+	return result
+}
+
+// Difference returns a new set containing the elements of s that are not in other.
+func (s Set[T]) Difference(other Set[T]) Set[T] {
+	result := make(Set[T])
+	for item := range s {
+		if !other.Contain(item) {
+			result[item] = struct{}{}
+		}
+	}
+	return result
+}
+
+// Equal reports whether s and other contain exactly the same elements.
+func (s Set[T]) Equal(other Set[T]) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	return s.IsSubset(other)
+}
+
+// IsSubset reports whether every element of s is also in other.
+func (s Set[T]) IsSubset(other Set[T]) bool {
+	if len(s) > len(other) {
+		return false
+	}
+	for item := range s {
+		if !other.Contain(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSuperset reports whether s contains every element of other.
+func (s Set[T]) IsSuperset(other Set[T]) bool {
+	return other.IsSubset(s)
+}
+
+// MarshalJSON encodes the set as a JSON array, e.g. ["a","b"].
+// The order of the elements in the array is arbitrary, as with ToSlice.
+func (s Set[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.ToSlice())
+}
+
+// UnmarshalJSON decodes a JSON array into the set, replacing its previous contents.
+// Duplicate elements in the array are collapsed.
+func (s *Set[T]) UnmarshalJSON(data []byte) error {
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+
+	*s = NewSet(items...)
+	return nil
+}
@@ -26,6 +26,22 @@ func ExampleSelectAll() {
 	// Output: [Alice Bob]
 }
 
+// ExampleSelectIf demonstrates a selector that only includes the fields of matching elements.
+func ExampleSelectIf() {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 17},
+	}
+
+	adults := SelectIf(func(u User) string { return u.Name }, func(u User) bool { return u.Age >= 18 })
+
+	fmt.Println(PluckFn(users, adults))
+	fmt.Println(PluckFn(users, SelectNone[User, string]()))
+	// Output:
+	// [Alice]
+	// []
+}
+
 // ExamplePluckFn demonstrates how to extract field values from a slice of structs.
 func ExamplePluckFn() {
 	users := []User{
@@ -49,21 +65,12 @@ func ExamplePluckFn_conditional() {
 	}
 
 	// Extract names only for users older than 28
-	names := PluckFn(users, func(u User) (string, bool) {
-		if u.Age > 28 {
-			return u.Name, true
-		}
-		return "", false
-	})
+	names := PluckFn(users, SelectIf(
+		func(u User) string { return u.Name },
+		func(u User) bool { return u.Age > 28 },
+	))
 
-	// Filter out empty strings that were skipped
-	var filtered []string
-	for _, name := range names {
-		if name != "" {
-			filtered = append(filtered, name)
-		}
-	}
-	fmt.Println(filtered)
+	fmt.Println(names)
 	// Output: [Alice Charlie]
 }
 
@@ -91,9 +98,6 @@ func ExamplePluckUniqFn() {
 
 	// Extract unique names
 	uniqueNames := PluckUniqFn(users, SelectAll(func(u User) string { return u.Name }))
-
-	// Sort for consistent output
-	sort.Strings(uniqueNames)
 	fmt.Println(uniqueNames)
 	// Output: [Alice Bob]
 }
@@ -157,12 +161,7 @@ func ExampleFieldMapFieldFn_conditional() {
 	// Map Name to Age, but only for users older than 28
 	ageByName := FieldMapFieldFn(
 		users,
-		func(u User) (string, bool) {
-			if u.Age > 28 {
-				return u.Name, true
-			}
-			return "", false
-		},
+		SelectIf(func(u User) string { return u.Name }, func(u User) bool { return u.Age > 28 }),
 		SelectAll(func(u User) int { return u.Age }),
 	)
 
@@ -225,24 +224,20 @@ func ExampleSetCmp_strings() {
 
 // ExampleUniq demonstrates removing duplicate elements from a slice.
 func ExampleUniq() {
-	numbers := []int{1, 2, 2, 3, 3, 3, 4, 5, 5}
+	numbers := []int{3, 1, 3, 2, 1}
 	unique := Uniq(numbers)
 
-	// Sort for consistent output (Uniq doesn't guarantee order)
-	sort.Ints(unique)
+	// Elements keep their first-seen order
 	fmt.Println(unique)
-	// Output: [1 2 3 4 5]
+	// Output: [3 1 2]
 }
 
 // ExampleUniq_strings demonstrates Uniq with strings.
 func ExampleUniq_strings() {
 	words := []string{"hello", "world", "hello", "go", "world"}
 	unique := Uniq(words)
-
-	// Sort for consistent output
-	sort.Strings(unique)
 	fmt.Println(unique)
-	// Output: [go hello world]
+	// Output: [hello world go]
 }
 
 // ExampleNewSet demonstrates creating a new set and checking membership.
@@ -264,8 +259,7 @@ func ExampleSet_Add() {
 	set.Add("banana")
 	set.Add("apple") // Duplicate, won't be added again
 
-	slice := set.ToSlice()
-	sort.Strings(slice) // Sort for consistent output
+	slice := SortedToSlice(set)
 	fmt.Println(slice)
 	// Output: [apple banana]
 }
@@ -306,6 +300,14 @@ func ExampleSet_ToSlice() {
 	// Output: [bird cat dog]
 }
 
+// ExampleSortedToSlice demonstrates converting a set to a sorted slice.
+func ExampleSortedToSlice() {
+	set := NewSet(3, 1, 2)
+
+	fmt.Println(SortedToSlice(set))
+	// Output: [1 2 3]
+}
+
 // ExampleSet_workflow demonstrates a complete workflow with a set.
 func ExampleSet_workflow() {
 	// Create a set to track unique visitor IDs
@@ -328,8 +330,7 @@ func ExampleSet_workflow() {
 	fmt.Println("After 102 left:", visitors.Contain(102))
 
 	// Get all current visitors
-	currentVisitors := visitors.ToSlice()
-	sort.Ints(currentVisitors)
+	currentVisitors := SortedToSlice(visitors)
 	fmt.Println("Current visitors:", currentVisitors)
 	// Output:
 	// Total unique visitors: 3
@@ -338,3 +339,116 @@ func ExampleSet_workflow() {
 	// After 102 left: false
 	// Current visitors: [101 103]
 }
+
+// ExampleMap demonstrates transforming a slice of structs into another type.
+func ExampleMap() {
+	type UserDTO struct {
+		Label string
+	}
+
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 25},
+	}
+
+	dtos := Map(users, func(u User) UserDTO {
+		return UserDTO{Label: fmt.Sprintf("%d:%s", u.ID, u.Name)}
+	})
+	fmt.Println(dtos)
+	// Output: [{1:Alice} {2:Bob}]
+}
+
+// ExampleMap_ints demonstrates converting ints to strings.
+func ExampleMap_ints() {
+	numbers := []int{1, 2, 3}
+
+	strs := Map(numbers, func(n int) string { return fmt.Sprintf("#%d", n) })
+	fmt.Println(strs)
+	// Output: [#1 #2 #3]
+}
+
+// ExampleFilter demonstrates keeping only the users older than 28.
+func ExampleFilter() {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 25},
+		{ID: 3, Name: "Charlie", Age: 35},
+	}
+
+	older := Filter(users, func(u User) bool { return u.Age > 28 })
+	fmt.Println(PluckFn(older, SelectAll(func(u User) string { return u.Name })))
+	// Output: [Alice Charlie]
+}
+
+// ExampleReject demonstrates dropping the users older than 28.
+func ExampleReject() {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 25},
+		{ID: 3, Name: "Charlie", Age: 35},
+	}
+
+	younger := Reject(users, func(u User) bool { return u.Age > 28 })
+	fmt.Println(PluckFn(younger, SelectAll(func(u User) string { return u.Name })))
+	// Output: [Bob]
+}
+
+// ExampleReduce demonstrates summing the Age field of a slice of users.
+func ExampleReduce() {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 25},
+		{ID: 3, Name: "Charlie", Age: 35},
+	}
+
+	totalAge := Reduce(users, 0, func(acc int, u User) int { return acc + u.Age })
+	fmt.Println(totalAge)
+	// Output: 90
+}
+
+// ExampleGroupByFn demonstrates grouping users that share a name.
+func ExampleGroupByFn() {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 25},
+		{ID: 3, Name: "Alice", Age: 28},
+	}
+
+	byName := GroupByFn(users, SelectAll(func(u User) string { return u.Name }))
+
+	fmt.Println(PluckFn(byName["Alice"], SelectAll(func(u User) int { return u.ID })))
+	fmt.Println(PluckFn(byName["Bob"], SelectAll(func(u User) int { return u.ID })))
+	// Output:
+	// [1 3]
+	// [2]
+}
+
+// ExampleSet_Union demonstrates combining two sets.
+func ExampleSet_Union() {
+	a := NewSet(1, 2, 3)
+	b := NewSet(3, 4)
+
+	union := SortedToSlice(a.Union(b))
+	fmt.Println(union)
+	// Output: [1 2 3 4]
+}
+
+// ExampleSet_Intersect demonstrates finding the elements common to two sets.
+func ExampleSet_Intersect() {
+	a := NewSet(1, 2, 3)
+	b := NewSet(2, 3, 4)
+
+	common := SortedToSlice(a.Intersect(b))
+	fmt.Println(common)
+	// Output: [2 3]
+}
+
+// ExampleSet_Difference demonstrates finding the elements only in the receiver.
+func ExampleSet_Difference() {
+	a := NewSet(1, 2, 3)
+	b := NewSet(2, 3, 4)
+
+	onlyA := SortedToSlice(a.Difference(b))
+	fmt.Println(onlyA)
+	// Output: [1]
+}
@@ -0,0 +1,1026 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPluckFn_skip(t *testing.T) {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 25},
+		{ID: 3, Name: "Charlie", Age: 35},
+	}
+
+	skipID := func(id int) SelectFn[User, string] {
+		return func(u User) (string, bool) {
+			return u.Name, u.ID != id
+		}
+	}
+
+	tests := []struct {
+		name string
+		sel  SelectFn[User, string]
+		want []string
+	}{
+		{name: "skip first", sel: skipID(1), want: []string{"Bob", "Charlie"}},
+		{name: "skip middle", sel: skipID(2), want: []string{"Alice", "Charlie"}},
+		{name: "skip last", sel: skipID(3), want: []string{"Alice", "Bob"}},
+		{name: "skip none", sel: skipID(0), want: []string{"Alice", "Bob", "Charlie"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PluckFn(users, tt.sel); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PluckFn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPluckFnParallel(t *testing.T) {
+	users := make([]User, 1000)
+	for i := range users {
+		users[i] = User{ID: i, Name: fmt.Sprintf("user-%d", i), Age: i % 90}
+	}
+
+	// Skips every third user so the parallel path has to compact while keeping the order
+	sel := func(u User) (string, bool) {
+		return strings.ToUpper(u.Name), u.ID%3 != 0
+	}
+	want := PluckFn(users, sel)
+
+	for _, workers := range []int{-1, 0, 1, 2, 7, 16, 2000} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			if got := PluckFnParallel(users, sel, workers); !reflect.DeepEqual(got, want) {
+				t.Errorf("PluckFnParallel() differs from PluckFn: got %d values, want %d", len(got), len(want))
+			}
+		})
+	}
+
+	if got := PluckFnParallel([]User{}, sel, 4); got == nil || len(got) != 0 {
+		t.Errorf("PluckFnParallel(empty) = %#v, want empty non-nil slice", got)
+	}
+}
+
+func TestMap(t *testing.T) {
+	got := Map([]int{}, func(n int) int { return n * 2 })
+	if got == nil || len(got) != 0 {
+		t.Errorf("Map(empty) = %#v, want empty non-nil slice", got)
+	}
+
+	got = Map([]int{3, 1, 2}, func(n int) int { return n * 2 })
+	if want := []int{6, 2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Map() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterReject(t *testing.T) {
+	isEven := func(n int) bool { return n%2 == 0 }
+
+	tests := []struct {
+		name       string
+		list       []int
+		wantFilter []int
+		wantReject []int
+	}{
+		{name: "empty", list: []int{}, wantFilter: []int{}, wantReject: []int{}},
+		{name: "nil", list: nil, wantFilter: []int{}, wantReject: []int{}},
+		{name: "mixed", list: []int{5, 4, 3, 2, 1}, wantFilter: []int{4, 2}, wantReject: []int{5, 3, 1}},
+		{name: "all match", list: []int{2, 4}, wantFilter: []int{2, 4}, wantReject: []int{}},
+		{name: "none match", list: []int{1, 3}, wantFilter: []int{}, wantReject: []int{1, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Filter(tt.list, isEven); !reflect.DeepEqual(got, tt.wantFilter) {
+				t.Errorf("Filter() = %#v, want %#v", got, tt.wantFilter)
+			}
+			if got := Reject(tt.list, isEven); !reflect.DeepEqual(got, tt.wantReject) {
+				t.Errorf("Reject() = %#v, want %#v", got, tt.wantReject)
+			}
+		})
+	}
+}
+
+func TestPartition(t *testing.T) {
+	isEven := func(n int) bool { return n%2 == 0 }
+
+	tests := []struct {
+		name        string
+		list        []int
+		wantMatched []int
+		wantRest    []int
+	}{
+		{name: "empty", list: nil, wantMatched: []int{}, wantRest: []int{}},
+		{name: "all matched", list: []int{2, 4}, wantMatched: []int{2, 4}, wantRest: []int{}},
+		{name: "none matched", list: []int{1, 3}, wantMatched: []int{}, wantRest: []int{1, 3}},
+		{name: "mixed", list: []int{5, 4, 3, 2, 1}, wantMatched: []int{4, 2}, wantRest: []int{5, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, rest := Partition(tt.list, isEven)
+			if !reflect.DeepEqual(matched, tt.wantMatched) {
+				t.Errorf("Partition() matched = %#v, want %#v", matched, tt.wantMatched)
+			}
+			if !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("Partition() rest = %#v, want %#v", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestReduce(t *testing.T) {
+	if got := Reduce([]int{}, 42, func(acc, n int) int { return acc + n }); got != 42 {
+		t.Errorf("Reduce(empty) = %d, want 42", got)
+	}
+
+	got := Reduce([]string{"a", "b", "c"}, "", func(acc, s string) string { return acc + s })
+	if got != "abc" {
+		t.Errorf("Reduce() = %q, want %q", got, "abc")
+	}
+}
+
+func TestGroupByFn(t *testing.T) {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 25},
+		{ID: 3, Name: "Alice", Age: 28},
+		{ID: 4, Name: "Eve", Age: 17},
+	}
+
+	got := GroupByFn(users, func(u User) (string, bool) {
+		return u.Name, u.Age >= 18
+	})
+
+	want := map[string][]User{
+		"Alice": {users[0], users[2]},
+		"Bob":   {users[1]},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupByFn() = %v, want %v", got, want)
+	}
+}
+
+func TestFieldMapSliceFn(t *testing.T) {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 25},
+		{ID: 3, Name: "Charlie", Age: 30},
+		{ID: 4, Name: "", Age: 30},
+		{ID: 5, Name: "Eve", Age: 17},
+	}
+
+	got := FieldMapSliceFn(users,
+		func(u User) (int, bool) { return u.Age, u.Age >= 18 },
+		func(u User) (string, bool) { return u.Name, u.Name != "" },
+	)
+
+	want := map[int][]string{
+		30: {"Alice", "Charlie"},
+		25: {"Bob"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldMapSliceFn() = %v, want %v", got, want)
+	}
+}
+
+func TestSelectIf(t *testing.T) {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 17},
+		{ID: 3, Name: "Eve", Age: 18},
+	}
+
+	sel := SelectIf(func(u User) int { return u.ID }, func(u User) bool { return u.Age >= 18 })
+	if got, want := PluckFn(users, sel), []int{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PluckFn(SelectIf) = %v, want %v", got, want)
+	}
+	if id, ok := sel(users[1]); ok || id != 0 {
+		t.Errorf("SelectIf() on a skipped element = (%v, %v), want (0, false)", id, ok)
+	}
+	if got := PluckFn(users, SelectNone[User, int]()); len(got) != 0 {
+		t.Errorf("PluckFn(SelectNone) = %v, want empty", got)
+	}
+}
+
+func TestSelectMap(t *testing.T) {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 17},
+	}
+
+	calls := 0
+	upper := func(name string) string {
+		calls++
+		return strings.ToUpper(name)
+	}
+	adults := SelectIf(func(u User) string { return u.Name }, func(u User) bool { return u.Age >= 18 })
+	sel := SelectMap(adults, upper)
+
+	if got, want := PluckFn(users, sel), []string{"ALICE"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PluckFn(SelectMap) = %v, want %v", got, want)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1 as skipped elements are not mapped", calls)
+	}
+
+	got := FieldMapFieldFn(users, SelectMap(adults, upper), SelectAll(func(u User) int { return u.ID }))
+	if want := map[string]int{"ALICE": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldMapFieldFn(SelectMap) = %v, want %v", got, want)
+	}
+}
+
+func TestFieldMapStructUniqFn(t *testing.T) {
+	byName := SelectAll(func(u User) string { return u.Name })
+
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 25},
+	}
+	got, err := FieldMapStructUniqFn(users, byName)
+	if err != nil {
+		t.Fatalf("FieldMapStructUniqFn() unexpected error: %v", err)
+	}
+	if want := map[string]User{"Alice": users[0], "Bob": users[1]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldMapStructUniqFn() = %v, want %v", got, want)
+	}
+
+	users = append(users, User{ID: 3, Name: "Alice", Age: 28})
+	got, err = FieldMapStructUniqFn(users, byName)
+	if err == nil {
+		t.Fatalf("FieldMapStructUniqFn() = %v, want duplicate key error", got)
+	}
+	for _, fragment := range []string{"Alice", "ID:1", "ID:3"} {
+		if !strings.Contains(err.Error(), fragment) {
+			t.Errorf("error %q does not mention %q", err, fragment)
+		}
+	}
+}
+
+func TestAssociate(t *testing.T) {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 15},
+		{ID: 3, Name: "Alice", Age: 28},
+	}
+
+	got := Associate(users, func(u User) (string, int, bool) {
+		return u.Name, u.ID, u.Age >= 18
+	})
+
+	// Bob is skipped and the second Alice overrides the first
+	if want := map[string]int{"Alice": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Associate() = %v, want %v", got, want)
+	}
+}
+
+func TestPluckMap(t *testing.T) {
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 15},
+		{ID: 3, Name: "Eve", Age: 22},
+		{ID: 4, Name: "Alice", Age: 28},
+	}
+
+	tests := []struct {
+		name string
+		sel  func(User) (string, int, bool)
+		want map[string]int
+	}{
+		{
+			name: "all included",
+			sel:  func(u User) (string, int, bool) { return u.Name, u.Age, true },
+			want: map[string]int{"Alice": 28, "Bob": 15, "Eve": 22}, // the last Alice wins
+		},
+		{
+			name: "skips",
+			sel:  func(u User) (string, int, bool) { return u.Name, u.ID, u.Age >= 18 && u.ID < 4 },
+			want: map[string]int{"Alice": 1, "Eve": 3},
+		},
+		{
+			name: "all skipped",
+			sel:  func(u User) (string, int, bool) { return u.Name, u.ID, false },
+			want: map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PluckMap(users, tt.sel); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PluckMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet_Operations(t *testing.T) {
+	tests := []struct {
+		name         string
+		a, b         Set[int]
+		union        Set[int]
+		intersect    Set[int]
+		aDifferenceB Set[int]
+		bDifferenceA Set[int]
+	}{
+		{
+			name:         "disjoint",
+			a:            NewSet(1, 2),
+			b:            NewSet(3, 4),
+			union:        NewSet(1, 2, 3, 4),
+			intersect:    NewSet[int](),
+			aDifferenceB: NewSet(1, 2),
+			bDifferenceA: NewSet(3, 4),
+		},
+		{
+			name:         "overlapping",
+			a:            NewSet(1, 2, 3),
+			b:            NewSet(2, 3, 4),
+			union:        NewSet(1, 2, 3, 4),
+			intersect:    NewSet(2, 3),
+			aDifferenceB: NewSet(1),
+			bDifferenceA: NewSet(4),
+		},
+		{
+			name:         "identical",
+			a:            NewSet(1, 2),
+			b:            NewSet(1, 2),
+			union:        NewSet(1, 2),
+			intersect:    NewSet(1, 2),
+			aDifferenceB: NewSet[int](),
+			bDifferenceA: NewSet[int](),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aBefore, bBefore := NewSet(tt.a.ToSlice()...), NewSet(tt.b.ToSlice()...)
+
+			if got := tt.a.Union(tt.b); !reflect.DeepEqual(got, tt.union) {
+				t.Errorf("Union() = %v, want %v", got, tt.union)
+			}
+			if got := tt.a.Intersect(tt.b); !reflect.DeepEqual(got, tt.intersect) {
+				t.Errorf("Intersect() = %v, want %v", got, tt.intersect)
+			}
+			if got := tt.a.Difference(tt.b); !reflect.DeepEqual(got, tt.aDifferenceB) {
+				t.Errorf("a.Difference(b) = %v, want %v", got, tt.aDifferenceB)
+			}
+			if got := tt.b.Difference(tt.a); !reflect.DeepEqual(got, tt.bDifferenceA) {
+				t.Errorf("b.Difference(a) = %v, want %v", got, tt.bDifferenceA)
+			}
+
+			if !reflect.DeepEqual(tt.a, aBefore) || !reflect.DeepEqual(tt.b, bBefore) {
+				t.Error("set operations mutated their receivers")
+			}
+		})
+	}
+}
+
+func TestSet_EqualSubset(t *testing.T) {
+	tests := []struct {
+		name       string
+		a, b       Set[string]
+		equal      bool
+		isSubset   bool
+		isSuperset bool
+	}{
+		{name: "both empty", a: NewSet[string](), b: NewSet[string](), equal: true, isSubset: true, isSuperset: true},
+		{name: "empty and non-empty", a: NewSet[string](), b: NewSet("a"), isSubset: true},
+		{name: "proper subset", a: NewSet("a"), b: NewSet("a", "b"), isSubset: true},
+		{name: "proper superset", a: NewSet("a", "b"), b: NewSet("b"), isSuperset: true},
+		{name: "different insertion order", a: NewSet("a", "b", "c"), b: NewSet("c", "a", "b"), equal: true, isSubset: true, isSuperset: true},
+		{name: "same length different elements", a: NewSet("a", "b"), b: NewSet("a", "c")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.equal {
+				t.Errorf("Equal() = %v, want %v", got, tt.equal)
+			}
+			if got := tt.a.IsSubset(tt.b); got != tt.isSubset {
+				t.Errorf("IsSubset() = %v, want %v", got, tt.isSubset)
+			}
+			if got := tt.a.IsSuperset(tt.b); got != tt.isSuperset {
+				t.Errorf("IsSuperset() = %v, want %v", got, tt.isSuperset)
+			}
+		})
+	}
+}
+
+func TestSetCmpSet(t *testing.T) {
+	current := []int{1, 2, 3, 4, 4}
+	target := []int{3, 4, 5, 6, 6}
+
+	added, overlapped, deleted := SetCmpSet(current, target)
+
+	if want := NewSet(5, 6); !added.Equal(want) {
+		t.Errorf("added = %v, want %v", added, want)
+	}
+	if want := NewSet(3, 4); !overlapped.Equal(want) {
+		t.Errorf("overlapped = %v, want %v", overlapped, want)
+	}
+	if want := NewSet(1, 2); !deleted.Equal(want) {
+		t.Errorf("deleted = %v, want %v", deleted, want)
+	}
+
+	// The three buckets must be pairwise disjoint and together partition the union.
+	for _, pair := range [][2]Set[int]{{added, overlapped}, {added, deleted}, {overlapped, deleted}} {
+		if inter := pair[0].Intersect(pair[1]); len(inter) != 0 {
+			t.Errorf("buckets %v and %v overlap on %v", pair[0], pair[1], inter)
+		}
+	}
+	union := NewSet(current...).Union(NewSet(target...))
+	if got := added.Union(overlapped).Union(deleted); !got.Equal(union) {
+		t.Errorf("buckets union = %v, want %v", got, union)
+	}
+}
+
+func TestSet_JSONRoundTrip(t *testing.T) {
+	t.Run("int", func(t *testing.T) {
+		original := NewSet(3, 1, 2)
+
+		data, err := json.Marshal(original)
+		if err != nil {
+			t.Fatalf("Marshal() error: %v", err)
+		}
+
+		var decoded []int
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("set did not marshal as a JSON array: %s", data)
+		}
+
+		var got Set[int]
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("Unmarshal() error: %v", err)
+		}
+		if !got.Equal(original) {
+			t.Errorf("round trip = %v, want %v", got, original)
+		}
+	})
+
+	t.Run("string", func(t *testing.T) {
+		var got Set[string]
+		if err := json.Unmarshal([]byte(`["a","b","a"]`), &got); err != nil {
+			t.Fatalf("Unmarshal() error: %v", err)
+		}
+		if want := NewSet("a", "b"); !got.Equal(want) {
+			t.Errorf("Unmarshal() = %v, want %v", got, want)
+		}
+
+		data, err := json.Marshal(struct {
+			Tags Set[string] `json:"tags"`
+		}{Tags: NewSet("only")})
+		if err != nil {
+			t.Fatalf("Marshal() error: %v", err)
+		}
+		if want := `{"tags":["only"]}`; string(data) != want {
+			t.Errorf("Marshal() = %s, want %s", data, want)
+		}
+	})
+}
+
+func TestUniq_firstSeenOrder(t *testing.T) {
+	if got, want := Uniq([]int{3, 1, 3, 2, 1}), []int{3, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Uniq() = %v, want %v", got, want)
+	}
+}
+
+func TestSliceSetOps(t *testing.T) {
+	a := []int{5, 3, 1, 3, 4}
+	b := []int{4, 2, 3, 2}
+
+	tests := []struct {
+		name string
+		fn   func(a, b []int) []int
+		want []int
+	}{
+		{name: "difference", fn: SliceDifference[int], want: []int{5, 1}},
+		{name: "intersection", fn: SliceIntersection[int], want: []int{3, 4}},
+		{name: "union", fn: SliceUnion[int], want: []int{5, 3, 1, 4, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(a, b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if got := tt.fn(nil, nil); len(got) != 0 {
+				t.Errorf("got %v for nil inputs, want empty", got)
+			}
+		})
+	}
+}
+
+func TestFlatMap(t *testing.T) {
+	repeat := func(n int) []int {
+		if n == 0 {
+			return nil
+		}
+		out := make([]int, n)
+		for i := range out {
+			out[i] = n
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		list []int
+		want []int
+	}{
+		{name: "nil", list: nil, want: []int{}},
+		{name: "all empty", list: []int{0, 0}, want: []int{}},
+		{name: "mixed", list: []int{2, 0, 1, 3}, want: []int{2, 2, 1, 3, 3, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FlatMap(tt.list, repeat); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FlatMap(%v) = %#v, want %#v", tt.list, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name  string
+		lists [][]int
+		want  []int
+	}{
+		{name: "nil outer", lists: nil, want: []int{}},
+		{name: "empty outer", lists: [][]int{}, want: []int{}},
+		{name: "empty inner", lists: [][]int{{}, nil}, want: []int{}},
+		{name: "mixed", lists: [][]int{{1, 2}, nil, {}, {3}, {4, 5}}, want: []int{1, 2, 3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Flatten(tt.lists)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Flatten() = %#v, want %#v", got, tt.want)
+			}
+			if cap(got) != len(tt.want) {
+				t.Errorf("Flatten() cap = %d, want %d", cap(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestKeysValues(t *testing.T) {
+	m := map[string]int{"c": 3, "a": 1, "b": 2}
+
+	keys := Keys(m)
+	if len(keys) != len(m) || !NewSet(keys...).Equal(NewSet("a", "b", "c")) {
+		t.Errorf("Keys() = %v, want a, b and c", keys)
+	}
+
+	values := Values(m)
+	if len(values) != len(m) || !NewSet(values...).Equal(NewSet(1, 2, 3)) {
+		t.Errorf("Values() = %v, want 1, 2 and 3", values)
+	}
+
+	if got, want := SortedKeys(m), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortedKeys() = %v, want %v", got, want)
+	}
+
+	if got := Keys(map[string]int(nil)); got == nil || len(got) != 0 {
+		t.Errorf("Keys(nil) = %#v, want empty non-nil slice", got)
+	}
+}
+
+func TestMinMaxSumBy(t *testing.T) {
+	age := func(u User) int { return u.Age }
+
+	tests := []struct {
+		name    string
+		users   []User
+		wantMin User
+		wantMax User
+		wantOK  bool
+		wantSum int
+	}{
+		{name: "empty", users: nil},
+		{
+			name:    "single",
+			users:   []User{{ID: 1, Age: 30}},
+			wantMin: User{ID: 1, Age: 30}, wantMax: User{ID: 1, Age: 30}, wantOK: true, wantSum: 30,
+		},
+		{
+			name:    "ties keep the first",
+			users:   []User{{ID: 1, Age: 20}, {ID: 2, Age: 40}, {ID: 3, Age: 20}, {ID: 4, Age: 40}},
+			wantMin: User{ID: 1, Age: 20}, wantMax: User{ID: 2, Age: 40}, wantOK: true, wantSum: 120,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := MinBy(tt.users, age); got != tt.wantMin || ok != tt.wantOK {
+				t.Errorf("MinBy() = %v, %v, want %v, %v", got, ok, tt.wantMin, tt.wantOK)
+			}
+			if got, ok := MaxBy(tt.users, age); got != tt.wantMax || ok != tt.wantOK {
+				t.Errorf("MaxBy() = %v, %v, want %v, %v", got, ok, tt.wantMax, tt.wantOK)
+			}
+			if got := SumBy(tt.users, age); got != tt.wantSum {
+				t.Errorf("SumBy() = %v, want %v", got, tt.wantSum)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	users := []User{
+		{ID: 1, Name: "Alice"},
+		{ID: 2, Name: "Bob"},
+		{ID: 3, Name: "Bob"},
+	}
+
+	tests := []struct {
+		name      string
+		target    string
+		wantIndex int
+	}{
+		{name: "found at start", target: "Alice", wantIndex: 0},
+		{name: "first of duplicates", target: "Bob", wantIndex: 1},
+		{name: "not found", target: "Eve", wantIndex: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isTarget := func(u User) bool { return u.Name == tt.target }
+
+			if got := FindIndex(users, isTarget); got != tt.wantIndex {
+				t.Errorf("FindIndex() = %d, want %d", got, tt.wantIndex)
+			}
+
+			got, ok := Find(users, isTarget)
+			if ok != (tt.wantIndex >= 0) {
+				t.Fatalf("Find() ok = %v, want %v", ok, tt.wantIndex >= 0)
+			}
+			if ok && got != users[tt.wantIndex] {
+				t.Errorf("Find() = %v, want %v", got, users[tt.wantIndex])
+			}
+			if !ok && got != (User{}) {
+				t.Errorf("Find() = %v, want zero value", got)
+			}
+		})
+	}
+
+	if got := FindIndex(users, func(u User) bool { return u.ID == 3 }); got != 2 {
+		t.Errorf("FindIndex(last) = %d, want 2", got)
+	}
+}
+
+func TestContainsIndexOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		list      []string
+		target    string
+		wantIndex int
+	}{
+		{name: "empty", list: []string{}, target: "a", wantIndex: -1},
+		{name: "nil", list: nil, target: "a", wantIndex: -1},
+		{name: "absent", list: []string{"a", "b"}, target: "c", wantIndex: -1},
+		{name: "duplicates", list: []string{"a", "b", "a"}, target: "a", wantIndex: 0},
+		{name: "last", list: []string{"a", "b"}, target: "b", wantIndex: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IndexOf(tt.list, tt.target); got != tt.wantIndex {
+				t.Errorf("IndexOf() = %d, want %d", got, tt.wantIndex)
+			}
+			if got := Contains(tt.list, tt.target); got != (tt.wantIndex >= 0) {
+				t.Errorf("Contains() = %v, want %v", got, tt.wantIndex >= 0)
+			}
+		})
+	}
+}
+
+func TestReverse(t *testing.T) {
+	tests := []struct {
+		name string
+		list []int
+		want []int
+	}{
+		{name: "empty", list: []int{}, want: []int{}},
+		{name: "odd", list: []int{1, 2, 3}, want: []int{3, 2, 1}},
+		{name: "even", list: []int{1, 2, 3, 4}, want: []int{4, 3, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := slices.Clone(tt.list)
+
+			if got := Reverse(tt.list); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Reverse() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.list, before) {
+				t.Errorf("Reverse() mutated its input to %v", tt.list)
+			}
+
+			ReverseInPlace(tt.list)
+			if !reflect.DeepEqual(tt.list, tt.want) {
+				t.Errorf("ReverseInPlace() = %v, want %v", tt.list, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortedToSlice(t *testing.T) {
+	if got, want := SortedToSlice(NewSet(3, 1, 2, 1)), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortedToSlice(ints) = %v, want %v", got, want)
+	}
+	if got, want := SortedToSlice(NewSet("pear", "apple", "fig")), []string{"apple", "fig", "pear"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortedToSlice(strings) = %v, want %v", got, want)
+	}
+	if got := SortedToSlice(NewSet[int]()); got == nil || len(got) != 0 {
+		t.Errorf("SortedToSlice(empty) = %#v, want empty non-nil slice", got)
+	}
+}
+
+func TestSet_Clone(t *testing.T) {
+	original := NewSet(1, 2, 3)
+
+	clone := original.Clone()
+	if !clone.Equal(original) {
+		t.Fatalf("Clone() = %v, want %v", clone, original)
+	}
+
+	clone.Add(4)
+	clone.Remove(1)
+	if want := NewSet(1, 2, 3); !original.Equal(want) {
+		t.Errorf("mutating the clone changed the original to %v, want %v", original, want)
+	}
+}
+
+func TestSet_AddAllRemoveAll(t *testing.T) {
+	s := NewSet(1, 2)
+
+	s.AddAll(2, 3, 3, 4)
+	if want := NewSet(1, 2, 3, 4); !s.Equal(want) {
+		t.Errorf("AddAll() = %v, want %v", s, want)
+	}
+
+	s.AddAll([]int{4, 5}...)
+	if want := NewSet(1, 2, 3, 4, 5); !s.Equal(want) {
+		t.Errorf("AddAll(slice...) = %v, want %v", s, want)
+	}
+
+	s.RemoveAll(1, 1, 5, 9)
+	if want := NewSet(2, 3, 4); !s.Equal(want) {
+		t.Errorf("RemoveAll() = %v, want %v", s, want)
+	}
+
+	s.AddAll()
+	s.RemoveAll()
+	if len(s) != 3 {
+		t.Errorf("AddAll() and RemoveAll() without items changed the set to %v", s)
+	}
+}
+
+func TestZipUnzip(t *testing.T) {
+	tests := []struct {
+		name  string
+		ids   []int
+		names []string
+		want  []Pair[int, string]
+	}{
+		{name: "equal", ids: []int{1, 2}, names: []string{"a", "b"}, want: []Pair[int, string]{{1, "a"}, {2, "b"}}},
+		{name: "shorter first", ids: []int{1}, names: []string{"a", "b"}, want: []Pair[int, string]{{1, "a"}}},
+		{name: "shorter second", ids: []int{1, 2, 3}, names: []string{"a", "b"}, want: []Pair[int, string]{{1, "a"}, {2, "b"}}},
+		{name: "empty", ids: nil, names: []string{"a"}, want: []Pair[int, string]{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Zip(tt.ids, tt.names)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Zip() = %v, want %v", got, tt.want)
+			}
+
+			ids, names := Unzip(got)
+			if n := len(tt.want); !slices.Equal(ids, tt.ids[:n]) || !slices.Equal(names, tt.names[:n]) {
+				t.Errorf("Unzip() = %v, %v, want %v, %v", ids, names, tt.ids[:n], tt.names[:n])
+			}
+		})
+	}
+}
+
+func TestCountBy(t *testing.T) {
+	if got, want := Count([]int{3, 1, 3, 3, 2, 1}), map[int]int{1: 2, 2: 1, 3: 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Count() = %v, want %v", got, want)
+	}
+	if got := Count([]string{}); len(got) != 0 {
+		t.Errorf("Count(empty) = %v, want empty", got)
+	}
+
+	users := []User{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 25},
+		{ID: 3, Name: "Alice", Age: 28},
+	}
+	got := CountBy(users, func(u User) string { return u.Name })
+	if want := map[string]int{"Alice": 2, "Bob": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CountBy() = %v, want %v", got, want)
+	}
+}
+
+func TestTakeDrop(t *testing.T) {
+	list := []int{1, 2, 3, 4}
+
+	tests := []struct {
+		name     string
+		n        int
+		wantTake []int
+		wantDrop []int
+	}{
+		{name: "zero", n: 0, wantTake: []int{}, wantDrop: []int{1, 2, 3, 4}},
+		{name: "negative", n: -1, wantTake: []int{}, wantDrop: []int{1, 2, 3, 4}},
+		{name: "some", n: 3, wantTake: []int{1, 2, 3}, wantDrop: []int{4}},
+		{name: "length", n: 4, wantTake: []int{1, 2, 3, 4}, wantDrop: []int{}},
+		{name: "greater than length", n: 10, wantTake: []int{1, 2, 3, 4}, wantDrop: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Take(list, tt.n); !reflect.DeepEqual(got, tt.wantTake) {
+				t.Errorf("Take(%d) = %#v, want %#v", tt.n, got, tt.wantTake)
+			}
+			if got := Drop(list, tt.n); !reflect.DeepEqual(got, tt.wantDrop) {
+				t.Errorf("Drop(%d) = %#v, want %#v", tt.n, got, tt.wantDrop)
+			}
+		})
+	}
+
+	// The results are copies
+	Take(list, 2)[0] = 99
+	if list[0] != 1 {
+		t.Errorf("writing to the result of Take() changed the input to %v", list)
+	}
+}
+
+func TestTakeDropWhile(t *testing.T) {
+	isOdd := func(n int) bool { return n%2 == 1 }
+
+	tests := []struct {
+		name     string
+		list     []int
+		wantTake []int
+		wantDrop []int
+	}{
+		{name: "empty", list: nil, wantTake: []int{}, wantDrop: []int{}},
+		{name: "prefix", list: []int{1, 3, 4, 5}, wantTake: []int{1, 3}, wantDrop: []int{4, 5}},
+		{name: "all", list: []int{1, 3}, wantTake: []int{1, 3}, wantDrop: []int{}},
+		{name: "none", list: []int{2, 3}, wantTake: []int{}, wantDrop: []int{2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TakeWhile(tt.list, isOdd); !reflect.DeepEqual(got, tt.wantTake) {
+				t.Errorf("TakeWhile() = %#v, want %#v", got, tt.wantTake)
+			}
+			if got := DropWhile(tt.list, isOdd); !reflect.DeepEqual(got, tt.wantDrop) {
+				t.Errorf("DropWhile() = %#v, want %#v", got, tt.wantDrop)
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	list := []int{1, 2, 3, 4}
+
+	tests := []struct {
+		name string
+		size int
+		want [][]int
+	}{
+		{name: "size 1", size: 1, want: [][]int{{1}, {2}, {3}, {4}}},
+		{name: "size 2", size: 2, want: [][]int{{1, 2}, {2, 3}, {3, 4}}},
+		{name: "size equal to len", size: 4, want: [][]int{{1, 2, 3, 4}}},
+		{name: "oversized", size: 5, want: [][]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Window(list, tt.size); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Window(%d) = %v, want %v", tt.size, got, tt.want)
+			}
+		})
+	}
+
+	for _, size := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Window(%d) did not panic", size)
+				}
+			}()
+			Window(list, size)
+		}()
+	}
+}
+
+func TestPtrDeref(t *testing.T) {
+	v := 42
+	p := Ptr(v)
+	if *p != 42 {
+		t.Errorf("*Ptr(42) = %d, want 42", *p)
+	}
+	if *p = 7; v != 42 {
+		t.Errorf("Ptr() aliases its argument, v = %d", v)
+	}
+
+	if got := Deref(p, 0); got != 7 {
+		t.Errorf("Deref(p, 0) = %d, want 7", got)
+	}
+	if got := Deref(nil, "fallback"); got != "fallback" {
+		t.Errorf("Deref(nil, %q) = %q, want the fallback", "fallback", got)
+	}
+	if got := Deref(Ptr(""), "fallback"); got != "" {
+		t.Errorf("Deref(Ptr(\"\"), ...) = %q, want the pointed-to zero value", got)
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	strTests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{name: "first set", values: []string{"a", "b"}, want: "a"},
+		{name: "leading empties", values: []string{"", "", "c", "d"}, want: "c"},
+		{name: "all empty", values: []string{"", ""}, want: ""},
+		{name: "none", values: nil, want: ""},
+	}
+	for _, tt := range strTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Coalesce(tt.values...); got != tt.want {
+				t.Errorf("Coalesce(%q) = %q, want %q", tt.values, got, tt.want)
+			}
+		})
+	}
+
+	if got := Coalesce(0, 0, -1, 5); got != -1 {
+		t.Errorf("Coalesce(0, 0, -1, 5) = %d, want -1", got)
+	}
+	if got := Coalesce(0, 0); got != 0 {
+		t.Errorf("Coalesce(0, 0) = %d, want 0", got)
+	}
+}
+
+func TestToSet_roundTrip(t *testing.T) {
+	list := []int{3, 1, 3, 2, 1}
+
+	s := ToSet(list)
+	if want := NewSet(1, 2, 3); !s.Equal(want) {
+		t.Errorf("ToSet(%v) = %v, want %v", list, s, want)
+	}
+	if got, want := SortedToSlice(s), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortedToSlice(ToSet(%v)) = %v, want %v", list, got, want)
+	}
+
+	got := s.ToSlice()
+	slices.Sort(got)
+	if want := slices.Sorted(slices.Values(Uniq(list))); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToSet(%v).ToSlice() = %v, want the elements of %v", list, got, want)
+	}
+
+	if got := ToSet([]string(nil)); got == nil || len(got) != 0 {
+		t.Errorf("ToSet(nil) = %#v, want an empty usable set", got)
+	}
+}
+
+func TestMapValues(t *testing.T) {
+	ages := map[string]int{"Alice": 30, "Bob": 25}
+
+	got := MapValues(ages, func(age int) string { return strconv.Itoa(age) + "y" })
+	if want := map[string]string{"Alice": "30y", "Bob": "25y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapValues() = %v, want %v", got, want)
+	}
+	if got := MapValues(map[string]int(nil), strconv.Itoa); got == nil || len(got) != 0 {
+		t.Errorf("MapValues(nil) = %#v, want an empty map", got)
+	}
+}
+
+func TestMapKeys(t *testing.T) {
+	got := MapKeys(map[string]int{"alice": 1, "bob": 2}, strings.ToUpper)
+	if want := map[string]int{"ALICE": 1, "BOB": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapKeys() = %v, want %v", got, want)
+	}
+
+	// Alice and alice collide, one of their values is kept
+	got = MapKeys(map[string]int{"Alice": 1, "alice": 2, "Bob": 3}, strings.ToLower)
+	if len(got) != 2 || got["bob"] != 3 {
+		t.Errorf("MapKeys() with collision = %v, want 2 keys and bob: 3", got)
+	}
+	if v := got["alice"]; v != 1 && v != 2 {
+		t.Errorf("MapKeys() kept alice: %d, want one of the colliding values", v)
+	}
+}